@@ -110,7 +110,15 @@ function Login() {
                 {loading ? "Iniciando sesión..." : "Iniciar sesión"}
               </button>
             </div>
-            <div className="mt-6 text-center">
+            <div className="mt-6 text-center space-y-2">
+              <p className="text-sm text-gray-600">
+                <a
+                  href="/password/forgot"
+                  className="text-blue-600 hover:text-blue-500 font-medium"
+                >
+                  ¿Olvidaste tu contraseña?
+                </a>
+              </p>
               <p className="text-sm text-gray-600">
                 ¿No tienes cuenta?{" "}
                 <a
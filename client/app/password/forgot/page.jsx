@@ -0,0 +1,102 @@
+"use client";
+import { useState } from "react";
+import { apiUrl } from "@/constants";
+
+// Solicita el correo con el link a /password/reset
+function ForgotPassword() {
+  const [email, setEmail] = useState("");
+  const [error, setError] = useState(null);
+  const [fieldErrors, setFieldErrors] = useState({});
+  const [message, setMessage] = useState(null);
+  const [loading, setLoading] = useState(false);
+
+  const handleSubmit = async () => {
+    setError("");
+    setFieldErrors({});
+    setLoading(true);
+
+    try {
+      const response = await fetch(`${apiUrl}/password/forgot`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ email }),
+      });
+      const data = await response.json();
+      if (!response.ok) {
+        setError(data.error);
+        if (data.fields) {
+          setFieldErrors(data.fields);
+        }
+        return;
+      }
+      setMessage(data.message);
+    } catch (error) {
+      setError(
+        "Ocurrió un error al enviar el correo. Por favor, inténtalo de nuevo más tarde."
+      );
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-blue-50 to-indigo-100">
+      <div className="max-w-md w-full space-y-8 bg-white p-8 rounded-lg shadow-md">
+        <h1 className="text-2xl font-bold text-center text-gray-900 mb-2">
+          Recuperar contraseña
+        </h1>
+        {message ? (
+          <p className="text-center text-gray-600">{message}</p>
+        ) : (
+          <form action={handleSubmit}>
+            {error && (
+              <div className="mb-4 p-3 bg-red-100 border border-red-400 text-red-700 rounded">
+                {error}
+              </div>
+            )}
+            <div className="space-y-4">
+              <div>
+                <label
+                  htmlFor="email"
+                  className="block text-sm font-medium text-gray-700 mb-1"
+                >
+                  Email
+                </label>
+                <input
+                  type="email"
+                  id="email"
+                  value={email}
+                  onChange={(e) => setEmail(e.target.value)}
+                  className={`w-full px-3 py-2 border rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500 text-black ${
+                    fieldErrors.email ? "border-red-500" : "border-gray-300"
+                  }`}
+                  placeholder="tu@email.com"
+                  disabled={loading}
+                />
+                {fieldErrors.email && (
+                  <p className="mt-1 text-sm text-red-600">
+                    {fieldErrors.email}
+                  </p>
+                )}
+              </div>
+
+              <button
+                type="submit"
+                disabled={loading}
+                className={`w-full py-2 px-4 rounded-md text-white font-medium ${
+                  loading
+                    ? "bg-gray-400 cursor-not-allowed"
+                    : "bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-blue-500"
+                }`}
+              >
+                {loading ? "Enviando..." : "Enviar link"}
+              </button>
+            </div>
+          </form>
+        )}
+      </div>
+    </div>
+  );
+}
+
+export default ForgotPassword;
@@ -0,0 +1,124 @@
+"use client";
+import { useState } from "react";
+import { useSearchParams } from "next/navigation";
+import { apiUrl } from "@/constants";
+
+// Página a la que lleva el link del correo de recuperación
+// (/password/reset?token=...). Canjea el token por la contraseña nueva.
+function ResetPassword() {
+  const searchParams = useSearchParams();
+  const token = searchParams.get("token") || "";
+  const [password, setPassword] = useState("");
+  const [error, setError] = useState(null);
+  const [fieldErrors, setFieldErrors] = useState({});
+  const [done, setDone] = useState(false);
+  const [loading, setLoading] = useState(false);
+
+  const handleSubmit = async () => {
+    setError("");
+    setFieldErrors({});
+    setLoading(true);
+
+    try {
+      const response = await fetch(`${apiUrl}/password/reset`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ token, password }),
+      });
+      const data = await response.json();
+      if (!response.ok) {
+        setError(data.error);
+        if (data.fields) {
+          setFieldErrors(data.fields);
+        }
+        return;
+      }
+      setDone(true);
+    } catch (error) {
+      setError(
+        "Ocurrió un error al cambiar la contraseña. Por favor, inténtalo de nuevo más tarde."
+      );
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-blue-50 to-indigo-100">
+      <div className="max-w-md w-full space-y-8 bg-white p-8 rounded-lg shadow-md">
+        <h1 className="text-2xl font-bold text-center text-gray-900 mb-2">
+          Restablecer contraseña
+        </h1>
+        {done ? (
+          <p className="text-center text-gray-600">
+            Tu contraseña se actualizó y se cerraron tus sesiones abiertas.{" "}
+            <a
+              href="/login"
+              className="text-blue-600 hover:text-blue-500 font-medium"
+            >
+              Inicia sesión
+            </a>
+          </p>
+        ) : !token ? (
+          <p className="text-center text-gray-600">
+            El link no es válido.{" "}
+            <a
+              href="/password/forgot"
+              className="text-blue-600 hover:text-blue-500 font-medium"
+            >
+              Solicita uno nuevo
+            </a>
+          </p>
+        ) : (
+          <form action={handleSubmit}>
+            {error && (
+              <div className="mb-4 p-3 bg-red-100 border border-red-400 text-red-700 rounded">
+                {error}
+              </div>
+            )}
+            <div className="space-y-4">
+              <div>
+                <label
+                  htmlFor="password"
+                  className="block text-sm font-medium text-gray-700 mb-1"
+                >
+                  Contraseña nueva
+                </label>
+                <input
+                  type="password"
+                  id="password"
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                  className={`w-full px-3 py-2 border rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500 text-black ${
+                    fieldErrors.password ? "border-red-500" : "border-gray-300"
+                  }`}
+                  placeholder="Al menos 6 caracteres"
+                  disabled={loading}
+                />
+                {fieldErrors.password && (
+                  <p className="mt-1 text-sm text-red-600">
+                    {fieldErrors.password}
+                  </p>
+                )}
+              </div>
+
+              <button
+                type="submit"
+                disabled={loading}
+                className={`w-full py-2 px-4 rounded-md text-white font-medium ${
+                  loading
+                    ? "bg-gray-400 cursor-not-allowed"
+                    : "bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-blue-500"
+                }`}
+              >
+                {loading ? "Guardando..." : "Cambiar contraseña"}
+              </button>
+            </div>
+          </form>
+        )}
+      </div>
+    </div>
+  );
+}
+
+export default ResetPassword;
@@ -43,7 +43,11 @@ export default function EditRegistro({ params }) {
     setFieldErrors({});
 
     try {
-      const result = await editRegistro(registroId, formData);
+      const result = await editRegistro(
+        registroId,
+        formData,
+        searchParams.get("etag") || ""
+      );
       if (!result.success) {
         setFieldErrors(result.fields);
         return;
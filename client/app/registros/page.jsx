@@ -41,6 +41,7 @@ function Registros() {
       descripcion: registro?.logro?.descripcion || "",
       inicio_semana: registro?.registro.inicio_semana || "",
       fin_semana: registro?.registro.fin_semana || "",
+      etag: registro?.etag || "",
     });
 
     router.push(`/registros/${registroId}?${params.toString()}`);
@@ -54,7 +55,10 @@ function Registros() {
   const handleDeleteConfirm = async () => {
     if (!registroToDelete) return;
 
-    const success = await deleteRegistro(registroToDelete.registro.id_registro);
+    const success = await deleteRegistro(
+      registroToDelete.registro.id_registro,
+      registroToDelete.etag
+    );
 
     if (success) {
       setShowDeleteModal(false);
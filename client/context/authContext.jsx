@@ -6,6 +6,7 @@ import {
   useEffect,
   useCallback,
 } from "react";
+import { storeSession, clearSession } from "@/lib/authFetch";
 
 const AuthContext = createContext();
 
@@ -15,13 +16,6 @@ export function AuthProvider({ children }) {
 
   const API_BASE_URL = "http://localhost:4000";
 
-  // Configurar cookie con opciones de seguridad apropiadas
-  const setCookie = (name, value, days = 7) => {
-    const expires = new Date();
-    expires.setTime(expires.getTime() + days * 24 * 60 * 60 * 1000);
-    document.cookie = `${name}=${value};expires=${expires.toUTCString()};path=/;SameSite=Lax`;
-  };
-
   // Obtener valor de una cookie específica
   const getCookie = (name) => {
     const nameEQ = name + "=";
@@ -34,11 +28,6 @@ export function AuthProvider({ children }) {
     return null;
   };
 
-  // Eliminar cookie estableciendo fecha de expiración en el pasado
-  const deleteCookie = (name) => {
-    document.cookie = `${name}=;expires=Thu, 01 Jan 1970 00:00:00 UTC;path=/;`;
-  };
-
   // Intentar restaurar sesión desde cookie al cargar la aplicación
   useEffect(() => {
     const token = getCookie("auth-token");
@@ -63,8 +52,7 @@ export function AuthProvider({ children }) {
         setUser(JSON.parse(userInfo));
       }
     } catch (error) {
-      deleteCookie("auth-token");
-      localStorage.removeItem("user");
+      clearSession();
       setUser(null);
     } finally {
       setLoading(false);
@@ -85,8 +73,7 @@ export function AuthProvider({ children }) {
       const data = await response.json();
 
       if (response.ok) {
-        setCookie("auth-token", data.token);
-        localStorage.setItem("token", data.token);
+        storeSession(data);
         const userInfo = {
           id: data.user_id,
           email: email,
@@ -126,8 +113,7 @@ export function AuthProvider({ children }) {
       const data = await response.json();
 
       if (response.ok) {
-        setCookie("auth-token", data.token);
-        localStorage.setItem("token", data.token);
+        storeSession(data);
         const userInfo = {
           id: data.user_id,
           email: email,
@@ -152,11 +138,18 @@ export function AuthProvider({ children }) {
     }
   };
 
-  // Cerrar sesión y limpiar todos los datos de autenticación
+  // Cerrar sesión: revocar el refresh token en el servidor y limpiar todos
+  // los datos de autenticación
   const logout = useCallback(() => {
-    deleteCookie("auth-token");
-    localStorage.removeItem("token");
-    localStorage.removeItem("user");
+    const refreshToken = localStorage.getItem("refresh_token");
+    if (refreshToken) {
+      fetch(`${API_BASE_URL}/logout`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      }).catch(() => {});
+    }
+    clearSession();
     setUser(null);
   }, []);
 
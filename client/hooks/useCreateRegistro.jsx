@@ -1,5 +1,6 @@
 "use client";
 import { apiUrl } from "@/constants";
+import { authFetch } from "@/lib/authFetch";
 import { useState } from "react";
 
 export const useCreateRegistro = () => {
@@ -8,11 +9,10 @@ export const useCreateRegistro = () => {
   const createRegistro = async (newData) => {
     try {
       setCreating(true);
-      const response = await fetch(`${apiUrl}/registros`, {
+      const response = await authFetch(`${apiUrl}/registros`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
-          Authorization: `Bearer ${localStorage.getItem("token")}`,
         },
         body: JSON.stringify(newData),
       });
@@ -1,19 +1,20 @@
 "use client";
 import { apiUrl } from "@/constants";
+import { authFetch } from "@/lib/authFetch";
 import { useState } from "react";
 
 export const useDeleteRegistro = () => {
   const [error, setError] = useState(null);
   const [deleting, setDeleting] = useState(false);
 
-  const deleteRegistro = async (registroId) => {
+  const deleteRegistro = async (registroId, etag) => {
     try {
       setDeleting(true);
-      const response = await fetch(`${apiUrl}/registros/${registroId}`, {
+      const response = await authFetch(`${apiUrl}/registros/${registroId}`, {
         method: "DELETE",
         headers: {
           "Content-Type": "application/json",
-          Authorization: `Bearer ${localStorage.getItem("token")}`,
+          "If-Match": etag,
         },
       });
       if (!response.ok) {
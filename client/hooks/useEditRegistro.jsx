@@ -1,18 +1,19 @@
 "use client";
 import { apiUrl } from "@/constants";
+import { authFetch } from "@/lib/authFetch";
 import { useState } from "react";
 
 export const useEditRegistro = () => {
   const [editing, setEditing] = useState(false);
 
-  const editRegistro = async (registroId, updatedData) => {
+  const editRegistro = async (registroId, updatedData, etag) => {
     try {
       setEditing(true);
-      const response = await fetch(`${apiUrl}/registros/${registroId}`, {
+      const response = await authFetch(`${apiUrl}/registros/${registroId}`, {
         method: "PATCH",
         headers: {
           "Content-Type": "application/json",
-          Authorization: `Bearer ${localStorage.getItem("token")}`,
+          "If-Match": etag,
         },
         body: JSON.stringify(updatedData),
       });
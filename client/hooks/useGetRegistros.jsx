@@ -1,5 +1,6 @@
 "use client";
 import { apiUrl } from "@/constants";
+import { authFetch } from "@/lib/authFetch";
 import { useState, useEffect } from "react";
 
 export const useGetRegistros = () => {
@@ -10,11 +11,10 @@ export const useGetRegistros = () => {
   const getRegistros = async () => {
     setLoading(true);
     try {
-      const response = await fetch(`${apiUrl}/registros`, {
+      const response = await authFetch(`${apiUrl}/registros`, {
         method: "GET",
         headers: {
           "Content-Type": "application/json",
-          Authorization: `Bearer ${localStorage.getItem("token")}`,
         },
       });
       if (!response.ok) {
@@ -0,0 +1,76 @@
+import { apiUrl } from "@/constants";
+
+let refreshing = null;
+
+// Guardar los tokens de una sesión nueva (login, registro o refresh)
+export const storeSession = (data) => {
+  localStorage.setItem("token", data.token);
+  if (data.refresh_token) {
+    localStorage.setItem("refresh_token", data.refresh_token);
+  }
+  const expires = new Date();
+  expires.setTime(expires.getTime() + 7 * 24 * 60 * 60 * 1000);
+  document.cookie = `auth-token=${data.token};expires=${expires.toUTCString()};path=/;SameSite=Lax`;
+};
+
+// Eliminar los tokens guardados
+export const clearSession = () => {
+  document.cookie = "auth-token=;expires=Thu, 01 Jan 1970 00:00:00 UTC;path=/;";
+  localStorage.removeItem("token");
+  localStorage.removeItem("refresh_token");
+  localStorage.removeItem("user");
+};
+
+// Renovar el access token con el refresh token. Si varios requests expiran a
+// la vez, todos esperan el mismo refresh (el servidor rota el refresh token y
+// rechaza reusar el anterior).
+export const refreshSession = () => {
+  if (!refreshing) {
+    refreshing = (async () => {
+      const refreshToken = localStorage.getItem("refresh_token");
+      if (!refreshToken) {
+        return false;
+      }
+      try {
+        const response = await fetch(`${apiUrl}/token/refresh`, {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          body: JSON.stringify({ refresh_token: refreshToken }),
+        });
+        if (!response.ok) {
+          clearSession();
+          return false;
+        }
+        storeSession(await response.json());
+        return true;
+      } catch (error) {
+        return false;
+      }
+    })().finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+};
+
+// fetch con el access token actual. Si el servidor responde 401 (token
+// vencido), renueva la sesión una vez y repite el request.
+export const authFetch = async (url, options = {}) => {
+  const send = () =>
+    fetch(url, {
+      ...options,
+      headers: {
+        ...options.headers,
+        Authorization: `Bearer ${localStorage.getItem("token")}`,
+      },
+    });
+
+  let response = await send();
+  if (response.status === 401 && (await refreshSession())) {
+    response = await send();
+  }
+  if (response.status === 401) {
+    clearSession();
+  }
+  return response;
+};
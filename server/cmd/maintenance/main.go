@@ -0,0 +1,84 @@
+// maintenance agrupa tareas de mantenimiento de la base de datos que se corren a
+// mano o desde un cron. Usa la misma configuración (.env) que el servidor.
+//
+//	go run ./cmd/maintenance orphans          # solo reporta
+//	go run ./cmd/maintenance orphans -fix     # además los elimina
+package main
+
+import (
+	"crud-web/internal/database"
+	"crud-web/internal/models"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "uso: maintenance <tarea> [flags]\n\ntareas:\n  orphans   busca (y con -fix elimina) registros sin logros")
+		os.Exit(2)
+	}
+
+	err := godotenv.Load()
+	if err != nil {
+		logger.Warn("no .env file loaded", "error", err.Error())
+	}
+
+	db, err := database.Open()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	uow := &models.UnitOfWork{DB: db}
+
+	switch os.Args[1] {
+	case "orphans":
+		err = orphans(logger, uow, os.Args[2:])
+	default:
+		err = fmt.Errorf("unknown task %q", os.Args[1])
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// orphans reporta los registros que se quedaron sin logros. Con -fix los
+// elimina en una sola transacción.
+func orphans(logger *slog.Logger, uow *models.UnitOfWork, args []string) error {
+	flags := flag.NewFlagSet("orphans", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "Eliminar los huérfanos encontrados")
+	minAge := flags.Duration("min-age", time.Hour, "Ignorar registros modificados hace menos de este tiempo")
+	flags.Parse(args)
+
+	olderThan := time.Now().Add(-*minAge)
+
+	return uow.WithTx(func(repos models.Repos) error {
+		registros, err := repos.Registros.Dangling(olderThan)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("orphans found", "registros", registros)
+
+		if !*fix {
+			return nil
+		}
+
+		deleted, err := repos.Registros.DeleteDangling(olderThan)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("orphans deleted", "registros", deleted)
+		return nil
+	})
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"crud-web/internal/policy"
+	"crud-web/internal/validator"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+type roleUpdateForm struct {
+	Rol                 string `json:"rol"`
+	validator.Validator `json:"-"`
+}
+
+// requireAdmin es un middleware que solo deja pasar a usuarios con permiso de
+// administrar cuentas según la política. Debe ir después de requireAuth.
+func (app *application) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.authorize(w, r, policy.ManageUsers, 0, "Se requiere rol de administrador") {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminTargetUser obtiene el usuario indicado por {id} en la ruta. Responde 400
+// o 404 y regresa false si el id es inválido o no existe. Un administrador no
+// puede modificar su propia cuenta desde estas rutas, para evitar que se quite
+// el acceso por accidente.
+func (app *application) adminTargetUser(w http.ResponseWriter, r *http.Request) (models.User, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		app.writeJSON(w, map[string]string{
+			"error": "ID inválido",
+		})
+		return models.User{}, false
+	}
+
+	if id == getUserID(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		app.writeJSON(w, map[string]string{
+			"error": "No puedes modificar tu propia cuenta desde la administración",
+		})
+		return models.User{}, false
+	}
+
+	user, err := app.users.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			app.writeJSON(w, map[string]string{
+				"error": "Usuario no encontrado",
+			})
+			return models.User{}, false
+		}
+		app.serverError(w, r, err)
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// adminListUsers regresa todas las cuentas
+func (app *application) adminListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := app.users.List()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"users": users,
+	})
+}
+
+// adminDisableUser deshabilita una cuenta y revoca sus refresh tokens y sus
+// tokens de acceso personal. El usuario ya no puede iniciar sesión ni renovar
+// la sesión, y requireAuth rechaza sus access tokens vigentes.
+func (app *application) adminDisableUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.adminTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.users.SetDisabled(user.ID, true)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.refreshTokens.RevokeAllForUser(user.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.apiTokens.DeleteAllForUser(user.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.logger.Info("user disabled", "user_id", user.ID, "admin_id", getUserID(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Usuario deshabilitado",
+	})
+}
+
+// adminEnableUser vuelve a habilitar una cuenta deshabilitada
+func (app *application) adminEnableUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.adminTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.users.SetDisabled(user.ID, false)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.logger.Info("user enabled", "user_id", user.ID, "admin_id", getUserID(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Usuario habilitado",
+	})
+}
+
+// adminSetRole cambia el rol de una cuenta (promover o degradar). requireAuth
+// lee el rol de la base de datos, así que aplica desde el siguiente request;
+// además se revocan sus refresh tokens y sus tokens de acceso personal, que se
+// emitieron con los permisos del rol anterior.
+func (app *application) adminSetRole(w http.ResponseWriter, r *http.Request) {
+	var form roleUpdateForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.PermittedValue(form.Rol, models.Roles...), "rol", "Rol inválido")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	user, ok := app.adminTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	err = app.users.SetRole(user.ID, form.Rol)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.refreshTokens.RevokeAllForUser(user.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.apiTokens.DeleteAllForUser(user.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.logger.Info("user role changed", "user_id", user.ID, "rol", form.Rol, "admin_id", getUserID(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Rol actualizado",
+	})
+}
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"crud-web/internal/models"
+	"crud-web/internal/policy"
 	"crud-web/internal/validator"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -26,9 +34,18 @@ type registerForm struct {
 	validator.Validator `json:"-"`
 }
 
+type refreshForm struct {
+	RefreshToken string `json:"refresh_token"`
+	validator.Validator `json:"-"`
+}
+
 type Claims struct {
 	UserID int    `json:"user_id"`
 	Email  string `json:"email"`
+	Role   string `json:"role,omitempty"`
+	// VersionSesion es usuario.version_sesion al emitir el token; requireAuth
+	// rechaza el token si la versión cambió desde entonces.
+	VersionSesion int `json:"sv,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -46,34 +63,40 @@ func checkPassword(password, hash string) bool {
 	return err == nil
 }
 
-// generateToken crea un JWT token válido por 24 horas.
-// Incluye el userID y email en los claims para identificación.
-// El token se firma con el secret configurado en variables de entorno.
-func (app *application) generateToken(userID int, email string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// generateToken crea un JWT de acceso de corta duración (ver -access-token-ttl).
+// Incluye el userID, email y rol en los claims para identificación.
+// El token se firma con la llave activa de app.jwtKeys (RS256/EdDSA desde
+// -jwt-keys-dir, o HS256 con JWTSECRET si no se configuraron llaves).
+func (app *application) generateToken(user models.User) (string, error) {
+	expirationTime := time.Now().Add(app.accessTokenTTL)
 	
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:        user.ID,
+		Email:         user.Email,
+		Role:          user.Rol,
+		VersionSesion: user.VersionSesion,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    app.baseURL,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(app.jwtSecret))
+	return app.jwtKeys.Sign(claims)
 }
 
 // validateToken parsea un token y verifica que sea válido. Primero crear un objeto
 // tipo Claims para asignarle los valores del token recibido en los parámetros.
+// Solo se aceptan los algoritmos de las llaves configuradas, y la llave se elige
+// por el kid del header.
 // Si el token es válido, se regresa la variable claims
 func (app *application) validateToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 	
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(app.jwtSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, app.jwtKeys.Keyfunc,
+		jwt.WithValidMethods(app.jwtKeys.Methods()),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		return nil, err
@@ -86,14 +109,85 @@ func (app *application) validateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// generateOpaqueToken genera un token aleatorio de 256 bits codificado en base64 URL,
+// junto con el hash que se guarda en la base de datos. El token en claro solo se
+// le entrega al cliente.
+func generateOpaqueToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	_, err = rand.Read(b)
+	if err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashOpaqueToken(token), nil
+}
+
+// hashOpaqueToken calcula el hash SHA-256 en hexadecimal de un token opaco
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSession genera un access token y un refresh token para el usuario, iniciando
+// una familia nueva de refresh tokens (un login nuevo equivale a una familia).
+// Regresa el payload que se le entrega al cliente; "token" se mantiene como
+// nombre del access token por compatibilidad con clientes existentes.
+func (app *application) newSession(user models.User) (map[string]interface{}, error) {
+	familia := make([]byte, 16)
+	_, err := rand.Read(familia)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.refreshTokens.Insert(user.ID, hex.EncodeToString(familia), refreshHash, time.Now().Add(app.refreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	return app.sessionPayload(user, refreshToken)
+}
+
+// sessionPayload firma un access token nuevo y arma la respuesta con el refresh
+// token ya persistido
+func (app *application) sessionPayload(user models.User, refreshToken string) (map[string]interface{}, error) {
+	token, err := app.generateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"user_id":       user.ID,
+		"rol":           user.Rol,
+		"token":         token,
+		"token_type":    "Bearer",
+		"expires_in":    int(app.accessTokenTTL.Seconds()),
+		"refresh_token": refreshToken,
+	}, nil
+}
+
 // requireAuth es un middleware que valida tokens JWT en requests.
-// Extrae el token del header Authorization, lo valida, y agrega
-// X-User-ID y X-User-Email headers para uso en handlers posteriores.
-// Si el token es inválido, retorna 401 Unauthorized.
+// Extrae el token del header Authorization o, si no viene, de la cookie
+// HttpOnly cw_session (verificando el token CSRF), lo valida, y agrega
+// X-User-ID, X-User-Email, X-User-Role y X-Auth-Type headers para uso en
+// handlers posteriores.
+// También acepta tokens de acceso personal (prefijo cwpat_); sus scopes se
+// verifican con requireScope en cada ruta.
+// El rol y el estado de la cuenta se leen de la base de datos en cada request,
+// no de los claims, para que deshabilitar o cambiar el rol de un usuario surta
+// efecto de inmediato.
+// Si el token es inválido, o se emitió antes de que se revocaran las sesiones
+// del usuario (ver UsersModel.RevokeSessions), retorna 401 Unauthorized; si la
+// cuenta está deshabilitada, 403.
 func (app *application) requireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
+		cookie, cookieErr := r.Cookie(sessionCookieName)
+		if authHeader == "" && cookieErr != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
 			app.writeJSON(w, map[string]string{
@@ -103,6 +197,18 @@ func (app *application) requireAuth(next http.Handler) http.Handler {
 		}
 
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+		if authHeader == "" {
+			if !app.checkCSRF(r) {
+				app.csrfFailed(w)
+				return
+			}
+			tokenString = cookie.Value
+		}
+
+		if strings.HasPrefix(tokenString, apiTokenPrefix) {
+			app.authenticateAPIToken(w, r, next, tokenString)
+			return
+		}
 		
 		claims, err := app.validateToken(tokenString)
 		if err != nil {
@@ -114,13 +220,51 @@ func (app *application) requireAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		r.Header.Set("X-User-ID", strconv.Itoa(claims.UserID))
-		r.Header.Set("X-User-Email", claims.Email)
+		user, err := app.users.Get(claims.UserID)
+		if err != nil && !errors.Is(err, models.ErrNoRecord) {
+			app.serverError(w, r, err)
+			return
+		}
+		if err != nil || user.VersionSesion != claims.VersionSesion {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			app.writeJSON(w, map[string]string{
+				"error": "Invalid token",
+			})
+			return
+		}
+
+		if user.Deshabilitado {
+			app.accountDisabled(w)
+			return
+		}
+
+		r.Header.Set("X-User-ID", strconv.Itoa(user.ID))
+		r.Header.Set("X-User-Email", user.Email)
+		r.Header.Set("X-User-Role", user.Rol)
+		r.Header.Set("X-Auth-Type", authTypeSession)
+		r.Header.Del("X-Auth-Scopes")
 		
 		next.ServeHTTP(w, r)
 	})
 }
 
+// jwks publica las llaves públicas con las que se verifican los JWT, para que
+// otros servicios puedan validar tokens sin conocer ningún secret
+func (app *application) jwks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	app.writeJSON(w, app.jwtKeys.JWKS())
+}
+
+// accountDisabled responde 403 cuando un administrador deshabilitó la cuenta
+func (app *application) accountDisabled(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	app.writeJSON(w, map[string]string{
+		"error": "La cuenta está deshabilitada",
+	})
+}
+
 // getUserID extrae el ID del usuario desde el header X-User-ID del request.
 // Este header es establecido por el middleware requireAuth después de validar el JWT
 func getUserID(r *http.Request) int {
@@ -129,9 +273,35 @@ func getUserID(r *http.Request) int {
 	return userID
 }
 
+// getActor arma el policy.Actor del request a partir de los headers que
+// establece requireAuth
+func getActor(r *http.Request) policy.Actor {
+	return policy.Actor{
+		UserID: getUserID(r),
+		Role:   r.Header.Get("X-User-Role"),
+	}
+}
+
+// authorize verifica con la política de autorización si el usuario del request
+// puede realizar la acción sobre un recurso de ownerID. Si no puede, responde
+// 403 con el mensaje indicado y regresa false.
+func (app *application) authorize(w http.ResponseWriter, r *http.Request, action policy.Action, ownerID int, message string) bool {
+	if policy.Can(getActor(r), action, ownerID) {
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	app.writeJSON(w, map[string]string{
+		"error": message,
+	})
+	return false
+}
+
 // register maneja el registro de nuevos usuarios.
 // Valida los datos del formulario, verifica que el email no exista,
-// hashea la contraseña y crea el usuario en la base de datos.
+// hashea la contraseña y crea el usuario en la base de datos sin verificar,
+// enviándole un correo de verificación.
 // Retorna un JWT token si el registro es exitoso.
 func (app *application) register(w http.ResponseWriter, r *http.Request) {
 	var form registerForm
@@ -182,23 +352,37 @@ func (app *application) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := app.generateToken(userID, form.Email)
+	user := models.User{
+		ID:       userID,
+		Nombre:   form.Nombre,
+		Apellido: form.Apellido,
+		Email:    form.Email,
+		Rol:      models.RoleUser,
+	}
+
+	err = app.sendVerificationEmail(user)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	app.writeJSON(w, map[string]interface{}{
-		"message": "Usuario registrado exitosamente",
-		"user_id": userID,
-		"token":   token,
-	})
+	session, err := app.newSession(user)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	session["message"] = "Usuario registrado exitosamente. Revisa tu correo para verificar tu email"
+	session["verificado"] = false
+
+	app.writeSession(w, r, session)
 }
 
 // login maneja la autenticación de usuarios existentes.
 // Valida las credenciales (email y contraseña) contra la base de datos
-// y retorna un JWT token si las credenciales son correctas.
+// y retorna un JWT token si las credenciales son correctas. Los fallos se
+// cuentan por email y por IP; al superar el límite se responde 429. Si el usuario
+// tiene 2FA habilitado, en lugar de la sesión se regresa un token
+// "mfa_pending" que debe canjearse en /login/mfa junto con un código TOTP.
 func (app *application) login(w http.ResponseWriter, r *http.Request) {
 	var form loginForm
 
@@ -221,8 +405,13 @@ func (app *application) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if app.loginBlocked(w, r, form.Email) {
+		return
+	}
+
 	user, err := app.users.GetByEmail(form.Email)
 	if err != nil {
+		app.loginFailed(r, form.Email, "unknown_email")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
 		app.writeJSON(w, map[string]string{
@@ -232,6 +421,7 @@ func (app *application) login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !checkPassword(form.Password, user.Password) {
+		app.loginFailed(r, form.Email, "bad_password")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
 		app.writeJSON(w, map[string]string{
@@ -240,16 +430,156 @@ func (app *application) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := app.generateToken(user.ID, user.Email)
+	app.loginSucceeded(r, form.Email)
+
+	app.completeLogin(w, r, user)
+}
+
+// completeLogin termina cualquier flujo de login una vez que el usuario
+// demostró su identidad (contraseña, SSO, etc.): rechaza cuentas
+// deshabilitadas, pide el segundo factor si el usuario tiene 2FA y, si no,
+// emite la sesión.
+func (app *application) completeLogin(w http.ResponseWriter, r *http.Request, user models.User) {
+	if user.Deshabilitado {
+		app.accountDisabled(w)
+		return
+	}
+
+	if user.TOTPHabilitado {
+		app.writeMFAChallenge(w, r, user)
+		return
+	}
+
+	session, err := app.newSession(user)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
+	session["message"] = "Login exitoso"
+
+	app.writeSession(w, r, session)
+}
+
+// readRefreshToken obtiene el refresh token del body JSON o, si no viene ahí, de
+// la cookie cw_refresh; en ese caso exige también el token CSRF. Si no se puede
+// obtener, responde con el error correspondiente y regresa false.
+func (app *application) readRefreshToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var form refreshForm
+
+	if r.Header.Get("Content-Type") == "application/json" {
+		err := app.decodeJSON(r, &form)
+		if err != nil && !errors.Is(err, io.EOF) {
+			app.clientError(w, http.StatusBadRequest)
+			return "", false
+		}
+	}
+
+	if form.RefreshToken == "" {
+		cookie, err := r.Cookie(refreshCookieName)
+		if err == nil && cookie.Value != "" {
+			if !app.checkCSRF(r) {
+				app.csrfFailed(w)
+				return "", false
+			}
+			return cookie.Value, true
+		}
+	}
+
+	form.CheckField(validator.NotBlank(form.RefreshToken), "refresh_token", "Este campo no puede estar en blanco")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error": "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return "", false
+	}
+
+	return form.RefreshToken, true
+}
+
+// refreshToken canjea un refresh token por un access token nuevo y un refresh
+// token nuevo de la misma familia (rotación). Si se presenta un refresh token que
+// ya fue canjeado, se asume que fue robado y se revoca toda la familia, obligando
+// al usuario a iniciar sesión otra vez.
+func (app *application) refreshToken(w http.ResponseWriter, r *http.Request) {
+	refreshToken, ok := app.readRefreshToken(w, r)
+	if !ok {
+		return
+	}
+
+	newToken, newHash, err := generateOpaqueToken()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	old, err := app.refreshTokens.Rotate(hashOpaqueToken(refreshToken), newHash, time.Now().Add(app.refreshTokenTTL))
+	if err != nil {
+		if errors.Is(err, models.ErrTokenReused) {
+			app.logger.Warn("refresh token reused, family revoked", "user_id", old.UserID, "ip", r.RemoteAddr)
+		}
+		if errors.Is(err, models.ErrTokenReused) || errors.Is(err, models.ErrInvalidToken) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			app.writeJSON(w, map[string]string{
+				"error": "Refresh token inválido",
+			})
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	user, err := app.users.Get(old.UserID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if user.Deshabilitado {
+		app.accountDisabled(w)
+		return
+	}
+
+	session, err := app.sessionPayload(user, newToken)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.writeSession(w, r, session)
+}
+
+// logout revoca la familia completa del refresh token recibido, de modo que
+// ninguno de los refresh tokens emitidos desde ese login pueda volver a usarse.
+// El access token vigente expira por sí solo al terminar su TTL.
+func (app *application) logout(w http.ResponseWriter, r *http.Request) {
+	refreshToken, ok := app.readRefreshToken(w, r)
+	if !ok {
+		return
+	}
+
+	t, err := app.refreshTokens.GetByHash(hashOpaqueToken(refreshToken))
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if err == nil {
+		err = app.refreshTokens.RevokeFamily(t.Familia)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	app.clearSessionCookies(w)
 
 	w.Header().Set("Content-Type", "application/json")
 	app.writeJSON(w, map[string]interface{}{
-		"message": "Login exitoso",
-		"user_id": user.ID,
-		"token":   token,
+		"message": "Sesión cerrada exitosamente",
 	})
 }
\ No newline at end of file
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// registroETag es el ETag de un registro: su versión, que sube con cada
+// edición del registro o de cualquiera de sus logros.
+func registroETag(r models.RegistroConLogros) string {
+	return fmt.Sprintf(`"%d"`, r.Registro.Version)
+}
+
+// ifMatch indica si alguno de los ETags de If-Match es etag. If-Match usa
+// comparación fuerte, así que los ETags débiles (W/) nunca coinciden.
+func ifMatch(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch exige que el request traiga If-Match y que corresponda a la
+// versión actual del registro. Responde 428 si falta y 412 si no coincide.
+func (app *application) checkIfMatch(w http.ResponseWriter, r *http.Request, current models.RegistroConLogros) bool {
+	if r.Header.Get("If-Match") == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPreconditionRequired)
+		app.writeJSON(w, map[string]string{
+			"error": "Falta el header If-Match con el ETag del registro",
+		})
+		return false
+	}
+
+	if !ifMatch(r, registroETag(current)) {
+		app.preconditionFailed(w, current)
+		return false
+	}
+	return true
+}
+
+// preconditionFailed responde 412 con la versión actual del registro, para que
+// el cliente pueda mostrar los cambios y reintentar con el ETag nuevo.
+func (app *application) preconditionFailed(w http.ResponseWriter, current models.RegistroConLogros) {
+	w.Header().Set("ETag", registroETag(current))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	app.writeJSON(w, struct {
+		Error string `json:"error"`
+		registroResponse
+	}{
+		Error:            "El registro cambió desde la última vez que lo cargaste",
+		registroResponse: newRegistroResponse(current),
+	})
+}
+
+// editConflict responde cuando una escritura condicionada a la versión no
+// afectó ninguna fila: otro request modificó o eliminó el registro entre la
+// lectura y la escritura.
+func (app *application) editConflict(w http.ResponseWriter, r *http.Request, id int) {
+	current, err := app.registros.GetWithLogros(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.registroNotFound(w)
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+	app.preconditionFailed(w, current)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const (
+	sessionCookieName = "cw_session"
+	refreshCookieName = "cw_refresh"
+	csrfCookieName    = "cw_csrf"
+	csrfHeaderName    = "X-CSRF-Token"
+)
+
+// writeSession responde con la sesión recién emitida. En modo cookie
+// (-session-cookies) los tokens se guardan en cookies HttpOnly y se quitan del
+// body, de modo que el JavaScript del cliente nunca los vea; en su lugar se
+// regresa el token CSRF que el cliente debe mandar en el header X-CSRF-Token.
+func (app *application) writeSession(w http.ResponseWriter, r *http.Request, session map[string]interface{}) {
+	if app.sessionCookies {
+		csrfToken, _, err := generateOpaqueToken()
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		app.setCookie(w, sessionCookieName, session["token"].(string), app.accessTokenTTL, true)
+		app.setCookie(w, refreshCookieName, session["refresh_token"].(string), app.refreshTokenTTL, true)
+		app.setCookie(w, csrfCookieName, csrfToken, app.refreshTokenTTL, false)
+
+		delete(session, "token")
+		delete(session, "refresh_token")
+		session["token_type"] = "Cookie"
+		session["csrf_token"] = csrfToken
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, session)
+}
+
+// setCookie escribe una cookie de sesión con las opciones de seguridad comunes.
+// La cookie CSRF no es HttpOnly porque el cliente necesita leerla.
+func (app *application) setCookie(w http.ResponseWriter, name, value string, ttl time.Duration, httpOnly bool) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: httpOnly,
+		Secure:   app.cookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearSessionCookies borra las cookies de sesión del navegador
+func (app *application) clearSessionCookies(w http.ResponseWriter) {
+	for _, name := range []string{sessionCookieName, refreshCookieName, csrfCookieName} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: name != csrfCookieName,
+			Secure:   app.cookieSecure,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+}
+
+// checkCSRF protege los requests autenticados por cookie. Los métodos seguros
+// (GET, HEAD, OPTIONS) no cambian estado y pasan sin revisión. Para el resto se
+// exige que el header Origin, si viene, sea uno de los orígenes permitidos, y
+// que el header X-CSRF-Token coincida con la cookie cw_csrf (double submit):
+// otro sitio puede hacer que el navegador mande las cookies, pero no puede
+// leerlas para copiar el valor en el header.
+func (app *application) checkCSRF(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	if origin := r.Header.Get("Origin"); origin != "" && !slices.Contains(app.allowedOrigins(), origin) {
+		return false
+	}
+
+	cookie, err := r.Cookie(csrfCookieName)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+
+	header := r.Header.Get(csrfHeaderName)
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) == 1
+}
+
+// allowedOrigins regresa los orígenes desde los que se aceptan requests con
+// cookies: el del cliente web y el de la propia API
+func (app *application) allowedOrigins() []string {
+	var origins []string
+	for _, raw := range []string{app.clientURL, app.baseURL} {
+		u, err := url.Parse(raw)
+		if err == nil && u.Host != "" {
+			origins = append(origins, u.Scheme+"://"+u.Host)
+		}
+	}
+	return origins
+}
+
+// csrfFailed responde 403 cuando un request autenticado por cookie no pasa la
+// verificación CSRF
+func (app *application) csrfFailed(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	app.writeJSON(w, map[string]string{
+		"error": "Token CSRF inválido",
+	})
+}
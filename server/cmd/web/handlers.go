@@ -2,19 +2,24 @@ package main
 
 import (
 	"crud-web/internal/models"
+	"crud-web/internal/policy"
+	"crud-web/internal/search"
 	"crud-web/internal/validator"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type userCreateForm struct {
-    Nombre string `json: "nombre"`
-    Apellido string `json: "apellido"`
-    Email string `json: "email"`
-    Password string `json: "password"`
-    validator.Validator `json: "-"`
+    Nombre string `json:"nombre"`
+    Apellido string `json:"apellido"`
+    Email string `json:"email"`
+    Password string `json:"password"`
+    validator.Validator `json:"-"`
 }
 
 type registroCreateForm struct {
@@ -22,12 +27,38 @@ type registroCreateForm struct {
     Descripcion string `json:"descripcion"`    
     InicioSemana string `json:"inicio_semana"` 
     FinSemana    string `json:"fin_semana"`    
+    ISOWeek      string `json:"iso_week"`
     validator.Validator `json:"-"`
 }
 
+// registroResponse es la forma JSON de un registro junto con sus logros. Logro
+// es el logro principal (el primero), para los clientes que solo conocen un
+// logro por registro. ETag es el valor a mandar en If-Match para editarlo,
+// eliminarlo o restaurarlo.
+type registroResponse struct {
+    Registro models.Registro `json:"registro"`
+    Logro    models.Logro    `json:"logro"`
+    Logros   []models.Logro  `json:"logros,omitempty"`
+    ETag     string          `json:"etag,omitempty"`
+}
+
+// registroListResponse es la respuesta de GET /registros
+type registroListResponse struct {
+    Registros  []registroResponse `json:"registros"`
+    NextCursor *string            `json:"next_cursor"`
+    Total      int                `json:"total"`
+}
+
+func newRegistroResponse(r models.RegistroConLogros) registroResponse {
+    return registroResponse{Registro: r.Registro, Logro: r.Principal(), Logros: r.Logros, ETag: registroETag(r)}
+}
+
 // createRegistro maneja la creación de nuevos registros de logros.
-// Valida los datos del formulario, crea un logro en la BD, y luego
-// crea el registro asociado al usuario autenticado.
+// Valida los datos del formulario, crea el registro asociado al usuario
+// autenticado y luego su primer logro, ambos en una misma transacción. Si ya
+// existe un registro para esa semana se aplica la política -duplicate-weeks:
+// con reject responde 409 con el registro existente; con merge el logro se
+// agrega al final del registro existente.
 // Retorna JSON con el ID del registro creado o errores de validación.
 func (app *application) createRegistro(w http.ResponseWriter, r *http.Request){
     var form registroCreateForm
@@ -43,8 +74,6 @@ func (app *application) createRegistro(w http.ResponseWriter, r *http.Request){
     form.CheckField(validator.NotBlank(form.Titulo), "titulo", "Este campo no puede estar en blanco")
     form.CheckField(validator.MaxChars(form.Titulo, 100), "titulo", "Este campo no puede tener más de 100 caracteres")
 	form.CheckField(validator.NotBlank(form.Descripcion), "descripcion", "Este campo no puede estar en blanco")
-    form.CheckField(validator.NotBlank(form.InicioSemana), "inicio_semana", "La fecha de inicio no puede estar en blanco")
-    form.CheckField(validator.NotBlank(form.FinSemana), "fin_semana", "La fecha de fin no puede estar en blanco")
     form.CheckField(userID > 0, "id_usuario", "ID de usuario inválido")
 
     if !form.Valid() {
@@ -57,15 +86,7 @@ func (app *application) createRegistro(w http.ResponseWriter, r *http.Request){
         return
     }
 
-    inicioSemana, err := time.Parse("2006-01-02", form.InicioSemana)
-    if err != nil {
-        form.AddFieldError("inicio_semana", "Formato de fecha inválido (usar YYYY-MM-DD)")
-    }
-    
-    finSemana, err := time.Parse("2006-01-02", form.FinSemana)
-    if err != nil {
-        form.AddFieldError("fin_semana", "Formato de fecha inválido (usar YYYY-MM-DD)")
-    }
+    inicioSemana, finSemana := app.checkWeek(&form)
 
     if !form.Valid() {
         w.Header().Set("Content-Type", "application/json")
@@ -77,117 +98,347 @@ func (app *application) createRegistro(w http.ResponseWriter, r *http.Request){
         return
     }
 
-    idLogro, err := app.logros.Insert(form.Titulo, form.Descripcion)
+    var idLogro int
+    var registro models.Registro
+    var merged bool
+    err = app.uow.WithTx(func(repos models.Repos) error {
+        registro, merged, err = repos.Registros.Insert(userID, inicioSemana, finSemana, app.duplicateWeeks)
+        if err != nil {
+            return err
+        }
+
+        if merged {
+            err = repos.Registros.Touch(registro.ID_Registro, registro.Version)
+            if err != nil {
+                return err
+            }
+            registro.Version++
+        }
+
+        idLogro, err = repos.Logros.Insert(userID, registro.ID_Registro, form.Titulo, form.Descripcion)
+        return err
+    })
     if err != nil {
+        var duplicate *models.DuplicateWeekError
+        if errors.As(err, &duplicate) {
+            app.duplicateWeek(w, duplicate)
+            return
+        }
+        if errors.Is(err, models.ErrEditConflict) {
+            app.editConflict(w, r, registro.ID_Registro)
+            return
+        }
         app.serverError(w, r, err)
         return
     }
 
-    idRegistro, err := app.registros.Insert(userID, idLogro, inicioSemana, finSemana)
-    if err != nil {
-        app.serverError(w, r, err)
-        return
+    message := "Registro creado exitosamente"
+    if merged {
+        message = "Logro agregado a tu registro de esa semana"
     }
 
+    app.indexLogro(search.Document{
+        LogroID:      idLogro,
+        RegistroID:   registro.ID_Registro,
+        UserID:       userID,
+        InicioSemana: registro.InicioSemana,
+        FinSemana:    registro.FinSemana,
+        Titulo:       form.Titulo,
+        Descripcion:  form.Descripcion,
+    })
+
     w.Header().Set("Content-Type", "application/json")
     app.writeJSON(w, map[string]interface{}{
-        "message": "Registro creado exitosamente",
-        "id_registro": idRegistro,
+        "message": message,
+        "id_registro": registro.ID_Registro,
         "id_logro": idLogro,
+        "registro": registro,
     })
 }
 
-// viewRegistro maneja la visualización de todos los registros de un usuario.
-// Obtiene el id del usuario autenticado y retorna un json
-// con todos los registros que tenga el usuario
+// viewRegistro maneja la visualización de los registros de un usuario.
+// Obtiene el id del usuario autenticado y retorna un json con una página
+// de sus registros. Parámetros opcionales:
+//   - limit y cursor (el next_cursor de la respuesta anterior) para paginar;
+//     next_cursor es null en la última página.
+//   - from/to (YYYY-MM-DD): semanas que empiezan en o después de from y
+//     terminan en o antes de to.
+//   - q: texto a buscar en el título y la descripción de sus logros.
+//   - sort: -inicio_semana (por defecto), inicio_semana o titulo.
+//
+// total es el número de registros que cumplen los filtros.
 func (app *application) viewRegistro(w http.ResponseWriter, r *http.Request) {
 	userID := getUserID(r)
-	registros, err := app.registros.Latest(userID)
+
+	var v validator.Validator
+	query := r.URL.Query()
+
+	limit := defaultPageSize
+	if query.Has("limit") {
+		var err error
+		limit, err = strconv.Atoi(query.Get("limit"))
+		v.CheckField(err == nil && limit >= 1, "limit", "Debe ser un número entero positivo")
+		v.CheckField(limit <= app.maxPageSize, "limit", fmt.Sprintf("No puede ser mayor a %d", app.maxPageSize))
+	}
+
+	filter := models.RegistroFilter{
+		Texto: strings.TrimSpace(query.Get("q")),
+		Orden: models.SortInicioSemanaDesc,
+	}
+
+	if query.Get("sort") != "" {
+		filter.Orden = query.Get("sort")
+		v.CheckField(validator.PermittedValue(filter.Orden, models.RegistroSorts...), "sort", "Orden inválido (usar inicio_semana, -inicio_semana o titulo)")
+	}
+
+	v.CheckField(validator.MaxChars(filter.Texto, 100), "q", "La búsqueda no puede tener más de 100 caracteres")
+
+	if from := query.Get("from"); from != "" {
+		v.CheckField(validator.Date(from), "from", "Formato de fecha inválido (usar YYYY-MM-DD)")
+		desde, _ := time.Parse("2006-01-02", from)
+		filter.Desde = &desde
+	}
+	if to := query.Get("to"); to != "" {
+		v.CheckField(validator.Date(to), "to", "Formato de fecha inválido (usar YYYY-MM-DD)")
+		hasta, _ := time.Parse("2006-01-02", to)
+		filter.Hasta = &hasta
+	}
+	if v.Valid() && filter.Desde != nil && filter.Hasta != nil {
+		v.CheckField(!filter.Hasta.Before(*filter.Desde), "to", "Debe ser igual o posterior a from")
+	}
+
+	var after *models.RegistroCursor
+	if query.Get("cursor") != "" && v.Valid() {
+		cursor, err := decodeCursor(query.Get("cursor"), filter.Orden)
+		v.CheckField(err == nil, "cursor", "Cursor inválido")
+		after = &cursor
+	}
+
+	if !v.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": v.FieldErrors,
+		})
+		return
+	}
+
+	// Se pide un registro de más para saber si existe una página siguiente.
+	registros, err := app.registros.Page(userID, filter, after, limit+1)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	hasNext := len(registros) > limit
+	if hasNext {
+		registros = registros[:limit]
+	}
+
+	total, err := app.registros.Count(userID, filter)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	var registrosWithLogros []map[string]interface{}
-	
+	response := registroListResponse{
+		Registros: make([]registroResponse, 0, len(registros)),
+		Total:     total,
+	}
 	for _, registro := range registros {
-		logro, err := app.logros.Get(registro.ID_Logro)
-		if err != nil {
-			app.serverError(w, r, err)
-			return
-		}
-		
-		registroWithLogro := map[string]interface{}{
-			"registro": registro,
-			"logro": logro,
+		response.Registros = append(response.Registros, newRegistroResponse(registro))
+	}
+
+	if hasNext {
+		last := registros[len(registros)-1]
+		cursor := encodeCursor(filter.Orden, models.RegistroCursor{
+			InicioSemana: last.Registro.InicioSemana,
+			Titulo:       last.Principal().Titulo,
+			ID_Registro:  last.Registro.ID_Registro,
+		})
+		response.NextCursor = &cursor
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, response)
+}
+
+// loadRegistro obtiene el registro (con sus logros) indicado por {id} en la
+// ruta y revisa que el usuario pueda realizar action sobre él. Un id inválido
+// responde 404 igual que uno que no existe; sin permiso responde 403. En ambos
+// casos regresa false.
+func (app *application) loadRegistro(w http.ResponseWriter, r *http.Request, action policy.Action, message string) (models.RegistroConLogros, bool) {
+	return app.loadRegistroWith(w, r, app.registros.GetWithLogros, action, message)
+}
+
+// loadRegistroWith es como loadRegistro pero obtiene el registro con get, por
+// ejemplo para buscarlo en la papelera.
+func (app *application) loadRegistroWith(w http.ResponseWriter, r *http.Request, get func(int) (models.RegistroConLogros, error), action policy.Action, message string) (models.RegistroConLogros, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		app.registroNotFound(w)
+		return models.RegistroConLogros{}, false
+	}
+
+	registro, err := get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.registroNotFound(w)
+			return models.RegistroConLogros{}, false
 		}
-		registrosWithLogros = append(registrosWithLogros, registroWithLogro)
+		app.serverError(w, r, err)
+		return models.RegistroConLogros{}, false
 	}
 
+	if !app.authorize(w, r, action, registro.Registro.ID_Usuario, message) {
+		return models.RegistroConLogros{}, false
+	}
+	return registro, true
+}
+
+func (app *application) registroNotFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	app.writeJSON(w, map[string]string{
+		"error": "Registro no encontrado",
+	})
+}
+
+// duplicateWeek responde 409 con el registro que ya ocupa la semana, según la
+// política -duplicate-weeks.
+func (app *application) duplicateWeek(w http.ResponseWriter, duplicate *models.DuplicateWeekError) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
 	app.writeJSON(w, map[string]interface{}{
-		"registros": registrosWithLogros,
+		"error": "Ya tienes un registro para esa semana",
+		"registro_existente": map[string]interface{}{
+			"id_registro": duplicate.Existing.ID_Registro,
+			"href":        fmt.Sprintf("/registros/%d", duplicate.Existing.ID_Registro),
+		},
 	})
 }
 
-// editRegistro maneja la edición de un registro existente.
-// Valida los datos del formulario, primero edita un logro, y luego
-// edita el registro asociado al usuario autenticado.
-// Retorna un JSON con el id del registro editado, junto a un mensaje de éxito.
+// viewRegistroByID regresa un solo registro junto con sus logros, con las mismas
+// reglas de acceso que editRegistro. Incluye ETag y Last-Modified para que el
+// cliente pueda revalidar con If-None-Match/If-Modified-Since y recibir 304, y
+// para mandar el ETag en If-Match al editar o eliminar.
+func (app *application) viewRegistroByID(w http.ResponseWriter, r *http.Request) {
+	registro, ok := app.loadRegistro(w, r, policy.ReadRegistro, "No tienes permiso para ver este registro")
+	if !ok {
+		return
+	}
+
+	tag := registroETag(registro)
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Last-Modified", registro.ActualizadoEn.UTC().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if notModified(r, tag, registro.ActualizadoEn) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, newRegistroResponse(registro))
+}
+
+// editRegistro maneja la edición parcial de un registro existente
+// (PATCH). El body es un JSON Merge Patch (application/merge-patch+json o
+// application/json) o un JSON Patch (application/json-patch+json) sobre
+// {titulo, descripcion, inicio_semana, fin_semana}, donde titulo y descripcion
+// son los del logro principal; también se puede agregar iso_week para cambiar
+// la semana. Solo se validan los campos que cambian.
+// Exige If-Match con el ETag de la versión que el cliente editó; si el
+// registro cambió desde entonces responde 412 con la versión actual. Con
+// -duplicate-weeks reject o merge, mover la semana encima de otro registro
+// responde 409 igual que al crear.
 func (app *application) editRegistro(w http.ResponseWriter, r *http.Request) {
-	idStr := r.PathValue("id")
-	if idStr == "" {
-		app.clientError(w, http.StatusBadRequest)
+	existing, ok := app.loadRegistro(w, r, policy.UpdateRegistro, "No tienes permiso para editar este registro")
+	if !ok {
 		return
 	}
-	
-	id, err := strconv.Atoi(idStr)
-	if err != nil || id < 1 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		app.writeJSON(w, map[string]string{
-			"error": "ID inválido",
-		})
+
+	if !app.checkIfMatch(w, r, existing) {
 		return
 	}
 
-	userID := getUserID(r)
-	
-	existingRegistro, err := app.registros.Get(id)
+	original := registroDocument(existing)
+	form, err := applyPatch(w, r, original)
 	if err != nil {
-		if errors.Is(err, models.ErrNoRecord) {
+		switch {
+		case errors.Is(err, errUnsupportedPatch):
+			w.Header().Set("Accept-Patch", contentTypeMergePatch+", "+contentTypeJSONPatch)
+			app.clientError(w, http.StatusUnsupportedMediaType)
+		case errors.Is(err, errPatchTestFailed):
 			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusNotFound)
+			w.WriteHeader(http.StatusConflict)
 			app.writeJSON(w, map[string]string{
-				"error": "Registro no encontrado",
+				"error": "Falló una operación test del JSON Patch",
+			})
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			app.writeJSON(w, map[string]string{
+				"error": "Patch inválido: " + err.Error(),
 			})
-			return
 		}
-		app.serverError(w, r, err)
 		return
 	}
 
-	if existingRegistro.ID_Usuario != userID {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusForbidden)
-		app.writeJSON(w, map[string]string{
-			"error": "No tienes permiso para editar este registro",
-		})
+	changed := map[string]bool{
+		"titulo":      form.Titulo != original.Titulo,
+		"descripcion": form.Descripcion != original.Descripcion,
+		"semana":      form.ISOWeek != "" || form.InicioSemana != original.InicioSemana || form.FinSemana != original.FinSemana,
+	}
+
+	// iso_week reemplaza las fechas actuales si el patch no las cambió también
+	if form.ISOWeek != "" && form.InicioSemana == original.InicioSemana && form.FinSemana == original.FinSemana {
+		form.InicioSemana, form.FinSemana = "", ""
+	}
+
+	app.updateRegistro(w, r, existing, existing.Principal().ID_Logro, form, changed)
+}
+
+// replaceRegistro maneja el reemplazo completo de un registro (PUT). Todos
+// los campos son obligatorios, igual que al crear. También exige If-Match.
+func (app *application) replaceRegistro(w http.ResponseWriter, r *http.Request) {
+	existing, ok := app.loadRegistro(w, r, policy.UpdateRegistro, "No tienes permiso para editar este registro")
+	if !ok {
+		return
+	}
+
+	if !app.checkIfMatch(w, r, existing) {
 		return
 	}
 
 	var form registroCreateForm
-	err = app.decodeJSON(r, &form)
+	err := app.decodeJSON(r, &form)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
 		return
 	}
 
-	form.CheckField(validator.NotBlank(form.Titulo), "titulo", "Este campo no puede estar en blanco")
-	form.CheckField(validator.MaxChars(form.Titulo, 100), "titulo", "Este campo no puede tener más de 100 caracteres")
-	form.CheckField(validator.NotBlank(form.Descripcion), "descripcion", "Este campo no puede estar en blanco")
-	form.CheckField(validator.NotBlank(form.InicioSemana), "inicio_semana", "La fecha de inicio no puede estar en blanco")
-	form.CheckField(validator.NotBlank(form.FinSemana), "fin_semana", "La fecha de fin no puede estar en blanco")
+	app.updateRegistro(w, r, existing, existing.Principal().ID_Logro, form, map[string]bool{"titulo": true, "descripcion": true, "semana": true})
+}
+
+// updateRegistro valida los campos marcados en changed y guarda form sobre
+// existing: primero edita el logro logroID (titulo y descripcion) y luego el
+// registro (la semana), ambos en una misma transacción. Retorna un JSON con el
+// registro editado y el ETag nuevo.
+func (app *application) updateRegistro(w http.ResponseWriter, r *http.Request, existing models.RegistroConLogros, logroID int, form registroCreateForm, changed map[string]bool) {
+	if changed["titulo"] {
+		form.CheckField(validator.NotBlank(form.Titulo), "titulo", "Este campo no puede estar en blanco")
+		form.CheckField(validator.MaxChars(form.Titulo, 100), "titulo", "Este campo no puede tener más de 100 caracteres")
+	}
+	if changed["descripcion"] {
+		form.CheckField(validator.NotBlank(form.Descripcion), "descripcion", "Este campo no puede estar en blanco")
+	}
+
+	inicioSemana, finSemana := existing.Registro.InicioSemana, existing.Registro.FinSemana
+	if changed["semana"] && form.Valid() {
+		inicioSemana, finSemana = app.checkWeek(&form)
+	}
 
 	if !form.Valid() {
 		w.Header().Set("Content-Type", "application/json")
@@ -199,105 +450,115 @@ func (app *application) editRegistro(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	inicioSemana, err := time.Parse("2006-01-02", form.InicioSemana)
-	if err != nil {
-		form.AddFieldError("inicio_semana", "Formato de fecha inválido")
-	}
-	
-	finSemana, err := time.Parse("2006-01-02", form.FinSemana)
-	if err != nil {
-		form.AddFieldError("fin_semana", "Formato de fecha inválido")
-	}
+	id := existing.Registro.ID_Registro
+	logro, _ := existing.Logro(logroID)
+	updated := existing
+	updated.Logros = slices.Clone(existing.Logros)
+	updated.Registro.InicioSemana, updated.Registro.FinSemana = inicioSemana, finSemana
 
-	if !form.Valid() {
+	logroChanged := form.Titulo != logro.Titulo || form.Descripcion != logro.Descripcion
+	semanaChanged := !inicioSemana.Equal(existing.Registro.InicioSemana) || !finSemana.Equal(existing.Registro.FinSemana)
+
+	if !logroChanged && !semanaChanged {
+		w.Header().Set("ETag", registroETag(existing))
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnprocessableEntity)
 		app.writeJSON(w, map[string]interface{}{
-			"error": "validation failed",
-			"fields": form.FieldErrors,
+			"message": "Sin cambios",
+			"id": id,
 		})
 		return
 	}
 
-	err = app.logros.Update(existingRegistro.ID_Logro, form.Titulo, form.Descripcion)
+	err := app.uow.WithTx(func(repos models.Repos) error {
+		if logroChanged {
+			err := repos.Logros.Update(logroID, logro.Version, getUserID(r), form.Titulo, form.Descripcion)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Con -duplicate-weeks reject o merge la semana nueva no puede caer
+		// encima de otro registro del usuario. Al editar no hay nada que
+		// fusionar, así que merge también responde 409.
+		if semanaChanged && app.duplicateWeeks != models.DuplicateAllow {
+			other, err := repos.Registros.Overlapping(existing.Registro.ID_Usuario, inicioSemana, finSemana, id)
+			if err == nil {
+				return &models.DuplicateWeekError{Existing: other}
+			} else if !errors.Is(err, models.ErrNoRecord) {
+				return err
+			}
+		}
+
+		// El registro siempre sube de versión para que cualquier edición
+		// invalide los ETags anteriores aunque solo cambie el logro.
+		return repos.Registros.Update(id, existing.Registro.Version, existing.Registro.ID_Usuario, inicioSemana, finSemana)
+	})
 	if err != nil {
+		var duplicate *models.DuplicateWeekError
+		if errors.As(err, &duplicate) {
+			app.duplicateWeek(w, duplicate)
+			return
+		}
+		if errors.Is(err, models.ErrEditConflict) {
+			app.editConflict(w, r, id)
+			return
+		}
 		app.serverError(w, r, err)
 		return
 	}
 
-	err = app.registros.Update(id, userID, existingRegistro.ID_Logro, inicioSemana, finSemana)
-	if err != nil {
-		app.serverError(w, r, err)
-		return
+	// WithTx puede repetir la función, así que la copia en memoria se
+	// actualiza hasta que la transacción hizo commit.
+	if logroChanged {
+		for i := range updated.Logros {
+			if updated.Logros[i].ID_Logro == logroID {
+				updated.Logros[i].Titulo, updated.Logros[i].Descripcion = form.Titulo, form.Descripcion
+				updated.Logros[i].Version++
+			}
+		}
 	}
+	updated.Registro.Version++
+
+	app.indexRegistro(updated)
 
+	w.Header().Set("ETag", registroETag(updated))
 	w.Header().Set("Content-Type", "application/json")
 	app.writeJSON(w, map[string]interface{}{
 		"message": "Registro actualizado exitosamente",
 		"id": id,
+		"registro": newRegistroResponse(updated),
 	})
 }
 
 // deleteRegistro maneja la eliminación de un registro existente.
-// Valida que el id del registro sea válido, y primero elimina el registro,
-// y después el logro. Regresa un JSON con un mensaje indicando que la eliminación
-// fue exitosa
+// Exige If-Match igual que editRegistro. El registro no se borra: pasa a la
+// papelera, de donde se puede restaurar hasta que el servidor lo purga.
+// Regresa un JSON con un mensaje indicando que la eliminación fue exitosa
 func (app *application) deleteRegistro(w http.ResponseWriter, r *http.Request) {
-	idStr := r.PathValue("id")
-	if idStr == "" {
-		app.clientError(w, http.StatusBadRequest)
+	existing, ok := app.loadRegistro(w, r, policy.DeleteRegistro, "No tienes permiso para eliminar este registro")
+	if !ok {
 		return
 	}
-	
-	id, err := strconv.Atoi(idStr)
-	if err != nil || id < 1 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		app.writeJSON(w, map[string]string{
-			"error": "ID inválido",
-		})
+
+	if !app.checkIfMatch(w, r, existing) {
 		return
 	}
 
-	userID := getUserID(r)
-	
-	existingRegistro, err := app.registros.Get(id)
+	id := existing.Registro.ID_Registro
+	err := app.registros.Trash(id, existing.Registro.Version)
 	if err != nil {
-		if errors.Is(err, models.ErrNoRecord) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusNotFound)
-			app.writeJSON(w, map[string]string{
-				"error": "Registro no encontrado",
-			})
+		if errors.Is(err, models.ErrEditConflict) {
+			app.editConflict(w, r, id)
 			return
 		}
 		app.serverError(w, r, err)
 		return
 	}
 
-	if existingRegistro.ID_Usuario != userID {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusForbidden)
-		app.writeJSON(w, map[string]string{
-			"error": "No tienes permiso para eliminar este registro",
-		})
-		return
-	}
-
-	err = app.registros.Delete(id)
-	if err != nil {
-		app.serverError(w, r, err)
-		return
-	}
-
-	err = app.logros.Delete(existingRegistro.ID_Logro)
-	if err != nil {
-		app.serverError(w, r, err)
-		return
-	}
+	app.unindexRegistro(existing)
 
 	w.Header().Set("Content-Type", "application/json")
 	app.writeJSON(w, map[string]interface{}{
 		"message": "Registro eliminado exitosamente",
 	})
-}
\ No newline at end of file
+}
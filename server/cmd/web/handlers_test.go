@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestViewRegistroByID(t *testing.T) {
+	actualizado := time.Date(2026, 10, 14, 9, 30, 15, 0, time.UTC)
+	lastModified := actualizado.Format(http.TimeFormat)
+
+	app := newTestApplication(t, fakeDB{query: func(stmt string, args []driver.Value) ([]string, [][]driver.Value) {
+		switch {
+		case strings.Contains(stmt, "FROM registro r"):
+			columns := []string{"id_registro", "id_usuario", "inicio_semana", "fin_semana", "version", "eliminado_en", "actualizado_en"}
+			if args[0] != int64(1) {
+				return columns, nil
+			}
+			return columns, [][]driver.Value{{
+				int64(1), int64(7), time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+				int64(3), nil, actualizado,
+			}}
+		case strings.Contains(stmt, "FROM logro"):
+			return []string{"id_logro", "id_registro", "posicion", "titulo", "descripcion", "version"}, [][]driver.Value{
+				{int64(10), int64(1), int64(0), "Migración", "Terminé la migración a MySQL 8", int64(1)},
+			}
+		}
+		t.Fatalf("unexpected query: %s", stmt)
+		return nil, nil
+	}})
+
+	tests := []struct {
+		name         string
+		id           string
+		userID       int
+		role         string
+		header       map[string]string
+		wantStatus   int
+		wantCacheHdr bool
+	}{
+		{name: "owner", id: "1", userID: 7, wantStatus: http.StatusOK, wantCacheHdr: true},
+		{name: "manager reads any registro", id: "1", userID: 8, role: models.RoleManager, wantStatus: http.StatusOK, wantCacheHdr: true},
+		{name: "not the owner", id: "1", userID: 8, role: models.RoleUser, wantStatus: http.StatusForbidden},
+		{name: "unknown id", id: "99", userID: 7, wantStatus: http.StatusNotFound},
+		{name: "id is not a number", id: "abc", userID: 7, wantStatus: http.StatusNotFound},
+		{name: "zero id", id: "0", userID: 7, wantStatus: http.StatusNotFound},
+		{name: "negative id", id: "-1", userID: 7, wantStatus: http.StatusNotFound},
+		{name: "if-none-match matches", id: "1", userID: 7, header: map[string]string{"If-None-Match": `"3"`}, wantStatus: http.StatusNotModified, wantCacheHdr: true},
+		{name: "weak if-none-match matches", id: "1", userID: 7, header: map[string]string{"If-None-Match": `"2", W/"3"`}, wantStatus: http.StatusNotModified, wantCacheHdr: true},
+		{name: "if-none-match is stale", id: "1", userID: 7, header: map[string]string{"If-None-Match": `"2"`}, wantStatus: http.StatusOK, wantCacheHdr: true},
+		{name: "if-modified-since matches", id: "1", userID: 7, header: map[string]string{"If-Modified-Since": lastModified}, wantStatus: http.StatusNotModified, wantCacheHdr: true},
+		{name: "if-modified-since is older", id: "1", userID: 7, header: map[string]string{"If-Modified-Since": actualizado.Add(-time.Second).Format(http.TimeFormat)}, wantStatus: http.StatusOK, wantCacheHdr: true},
+		{
+			name: "if-none-match wins over if-modified-since", id: "1", userID: 7,
+			header:     map[string]string{"If-None-Match": `"2"`, "If-Modified-Since": lastModified},
+			wantStatus: http.StatusOK, wantCacheHdr: true,
+		},
+		{name: "not modified still needs permission", id: "1", userID: 8, role: models.RoleUser, header: map[string]string{"If-None-Match": `"3"`}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/registros/"+tt.id, nil)
+			r.SetPathValue("id", tt.id)
+			r.Header.Set("X-User-ID", strconv.Itoa(tt.userID))
+			r.Header.Set("X-User-Role", tt.role)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+
+			app.viewRegistroByID(rr, r)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d; want %d (body %s)", rr.Code, tt.wantStatus, rr.Body)
+			}
+
+			if !tt.wantCacheHdr {
+				if got := rr.Header().Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q; want application/json", got)
+				}
+				if rr.Header().Get("ETag") != "" {
+					t.Errorf("error response has ETag %q", rr.Header().Get("ETag"))
+				}
+				return
+			}
+
+			if got := rr.Header().Get("ETag"); got != `"3"` {
+				t.Errorf("ETag = %q; want %q", got, `"3"`)
+			}
+			if got := rr.Header().Get("Last-Modified"); got != lastModified {
+				t.Errorf("Last-Modified = %q; want %q", got, lastModified)
+			}
+			if rr.Code == http.StatusNotModified && rr.Body.Len() != 0 {
+				t.Errorf("304 response has a body: %s", rr.Body)
+			}
+			if rr.Code == http.StatusOK && !strings.Contains(rr.Body.String(), `"Migración"`) {
+				t.Errorf("body does not include the logro: %s", rr.Body)
+			}
+		})
+	}
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"runtime/debug"
+	"strings"
+	"time"
 )
 
 // decodeJSON se encarga de decodificar el JSON recibido de requests.
@@ -46,3 +49,40 @@ func (app *application) serverError(w http.ResponseWriter, r *http.Request, err
 func (app *application) clientError(w http.ResponseWriter, status int) {
 	http.Error(w, http.StatusText(status), status)
 }
+
+
+// background ejecuta fn en una goroutine aparte, recuperando cualquier panic
+// para que no tumbe el servidor. Se usa para tareas que no deben retrasar la
+// respuesta, como enviar correos.
+func (app *application) background(fn func()) {
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Error(fmt.Sprintf("%s", err))
+			}
+		}()
+
+		fn()
+	}()
+}
+
+
+// notModified indica si el cliente ya tiene la versión actual del recurso.
+// If-None-Match tiene prioridad; If-Modified-Since solo se revisa si no viene.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
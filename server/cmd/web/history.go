@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crud-web/internal/diff"
+	"crud-web/internal/models"
+	"crud-web/internal/policy"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// revisionResponse es una revisión del logro junto con lo que cambió respecto a
+// la anterior. La primera revisión no trae cambios.
+type revisionResponse struct {
+	models.LogroRevision
+	Cambios map[string][]diff.Op `json:"cambios,omitempty"`
+}
+
+// viewHistory regresa el historial de un logro del registro ({logroId}, o el
+// principal en /registros/{id}/history), de la revisión más reciente a la más
+// antigua, con el diff palabra por palabra de titulo y descripcion contra la
+// revisión anterior.
+func (app *application) viewHistory(w http.ResponseWriter, r *http.Request) {
+	registro, ok := app.loadRegistro(w, r, policy.ReadRegistro, "No tienes permiso para ver este registro")
+	if !ok {
+		return
+	}
+
+	logro, ok := app.loadLogro(w, r, registro)
+	if !ok {
+		return
+	}
+
+	revisiones, err := app.revisions.ForLogro(logro.ID_Logro)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	response := make([]revisionResponse, len(revisiones))
+	for i, rev := range revisiones {
+		item := revisionResponse{LogroRevision: rev}
+		if i > 0 {
+			prev := revisiones[i-1]
+			item.Cambios = map[string][]diff.Op{}
+			if prev.Titulo != rev.Titulo {
+				item.Cambios["titulo"] = diff.Words(prev.Titulo, rev.Titulo)
+			}
+			if prev.Descripcion != rev.Descripcion {
+				item.Cambios["descripcion"] = diff.Words(prev.Descripcion, rev.Descripcion)
+			}
+		}
+		response[len(revisiones)-1-i] = item
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"id_registro": registro.Registro.ID_Registro,
+		"id_logro":    logro.ID_Logro,
+		"revision":    logro.Version,
+		"revisiones":  response,
+	})
+}
+
+// revertRevision deja el logro (el mismo que en viewHistory) con el titulo y
+// la descripcion de la revisión {rev}. No reescribe el historial: el contenido
+// restaurado se guarda como una revisión nueva. Exige If-Match igual que
+// editRegistro.
+func (app *application) revertRevision(w http.ResponseWriter, r *http.Request) {
+	existing, ok := app.loadRegistro(w, r, policy.UpdateRegistro, "No tienes permiso para editar este registro")
+	if !ok {
+		return
+	}
+
+	logro, ok := app.loadLogro(w, r, existing)
+	if !ok {
+		return
+	}
+
+	revisionNotFound := func() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		app.writeJSON(w, map[string]string{
+			"error": "Revisión no encontrada",
+		})
+	}
+
+	rev, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil || rev < 1 {
+		revisionNotFound()
+		return
+	}
+
+	if !app.checkIfMatch(w, r, existing) {
+		return
+	}
+
+	revision, err := app.revisions.Get(logro.ID_Logro, rev)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			revisionNotFound()
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	form := registroCreateForm{
+		Titulo:      revision.Titulo,
+		Descripcion: revision.Descripcion,
+	}
+	app.updateRegistro(w, r, existing, logro.ID_Logro, form, map[string]bool{"titulo": true, "descripcion": true})
+}
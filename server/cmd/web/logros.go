@@ -0,0 +1,283 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"crud-web/internal/policy"
+	"crud-web/internal/search"
+	"crud-web/internal/validator"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+type logroCreateForm struct {
+	Titulo              string `json:"titulo"`
+	Descripcion         string `json:"descripcion"`
+	validator.Validator `json:"-"`
+}
+
+type logroUpdateForm struct {
+	Titulo              *string `json:"titulo"`
+	Descripcion         *string `json:"descripcion"`
+	validator.Validator `json:"-"`
+}
+
+type logroReorderForm struct {
+	Logros              []int `json:"logros"`
+	validator.Validator `json:"-"`
+}
+
+// loadLogro obtiene el logro {logroId} de registro. Sin {logroId} en la ruta
+// regresa el logro principal. Si el id es inválido o el logro no es de este
+// registro responde 404 y regresa false.
+func (app *application) loadLogro(w http.ResponseWriter, r *http.Request, registro models.RegistroConLogros) (models.Logro, bool) {
+	if r.PathValue("logroId") == "" {
+		return registro.Principal(), true
+	}
+
+	id, err := strconv.Atoi(r.PathValue("logroId"))
+	if err == nil {
+		logro, ok := registro.Logro(id)
+		if ok {
+			return logro, true
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	app.writeJSON(w, map[string]string{
+		"error": "Logro no encontrado",
+	})
+	return models.Logro{}, false
+}
+
+// writeRegistro responde con la versión recién guardada del registro id y su
+// ETag nuevo, después de modificar sus logros.
+func (app *application) writeRegistro(w http.ResponseWriter, r *http.Request, id int, message string) {
+	registro, err := app.registros.GetWithLogros(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", registroETag(registro))
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message":  message,
+		"registro": newRegistroResponse(registro),
+	})
+}
+
+// createLogro agrega un logro al final de un registro existente. Exige
+// If-Match con el ETag del registro, igual que editRegistro.
+func (app *application) createLogro(w http.ResponseWriter, r *http.Request) {
+	existing, ok := app.loadRegistro(w, r, policy.UpdateRegistro, "No tienes permiso para editar este registro")
+	if !ok {
+		return
+	}
+
+	if !app.checkIfMatch(w, r, existing) {
+		return
+	}
+
+	var form logroCreateForm
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Titulo), "titulo", "Este campo no puede estar en blanco")
+	form.CheckField(validator.MaxChars(form.Titulo, 100), "titulo", "Este campo no puede tener más de 100 caracteres")
+	form.CheckField(validator.NotBlank(form.Descripcion), "descripcion", "Este campo no puede estar en blanco")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	id := existing.Registro.ID_Registro
+	var idLogro int
+	err = app.uow.WithTx(func(repos models.Repos) error {
+		err := repos.Registros.Touch(id, existing.Registro.Version)
+		if err != nil {
+			return err
+		}
+
+		idLogro, err = repos.Logros.Insert(getUserID(r), id, form.Titulo, form.Descripcion)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrEditConflict) {
+			app.editConflict(w, r, id)
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.indexLogro(search.Document{
+		LogroID:      idLogro,
+		RegistroID:   id,
+		UserID:       existing.Registro.ID_Usuario,
+		InicioSemana: existing.Registro.InicioSemana,
+		FinSemana:    existing.Registro.FinSemana,
+		Titulo:       form.Titulo,
+		Descripcion:  form.Descripcion,
+	})
+
+	app.writeRegistro(w, r, id, "Logro agregado exitosamente")
+}
+
+// updateLogro edita el titulo y/o la descripcion de uno de los logros del
+// registro. Solo se validan y cambian los campos presentes en el body. Exige
+// If-Match con el ETag del registro.
+func (app *application) updateLogro(w http.ResponseWriter, r *http.Request) {
+	existing, ok := app.loadRegistro(w, r, policy.UpdateRegistro, "No tienes permiso para editar este registro")
+	if !ok {
+		return
+	}
+
+	logro, ok := app.loadLogro(w, r, existing)
+	if !ok {
+		return
+	}
+
+	if !app.checkIfMatch(w, r, existing) {
+		return
+	}
+
+	var patch logroUpdateForm
+	err := app.decodeJSON(r, &patch)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form := registroCreateForm{Titulo: logro.Titulo, Descripcion: logro.Descripcion}
+	if patch.Titulo != nil {
+		form.Titulo = *patch.Titulo
+	}
+	if patch.Descripcion != nil {
+		form.Descripcion = *patch.Descripcion
+	}
+
+	app.updateRegistro(w, r, existing, logro.ID_Logro, form, map[string]bool{
+		"titulo":      patch.Titulo != nil,
+		"descripcion": patch.Descripcion != nil,
+	})
+}
+
+// deleteLogro elimina uno de los logros del registro. El último logro no se
+// puede eliminar: para eso se elimina el registro. Exige If-Match con el ETag
+// del registro.
+func (app *application) deleteLogro(w http.ResponseWriter, r *http.Request) {
+	existing, ok := app.loadRegistro(w, r, policy.UpdateRegistro, "No tienes permiso para editar este registro")
+	if !ok {
+		return
+	}
+
+	logro, ok := app.loadLogro(w, r, existing)
+	if !ok {
+		return
+	}
+
+	if !app.checkIfMatch(w, r, existing) {
+		return
+	}
+
+	if len(existing.Logros) == 1 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		app.writeJSON(w, map[string]string{
+			"error": "Un registro debe tener al menos un logro; elimina el registro",
+		})
+		return
+	}
+
+	id := existing.Registro.ID_Registro
+	err := app.uow.WithTx(func(repos models.Repos) error {
+		err := repos.Registros.Touch(id, existing.Registro.Version)
+		if err != nil {
+			return err
+		}
+		return repos.Logros.Delete(logro.ID_Logro, logro.Version)
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrEditConflict) {
+			app.editConflict(w, r, id)
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.unindexLogro(logro.ID_Logro)
+
+	app.writeRegistro(w, r, id, "Logro eliminado exitosamente")
+}
+
+// reorderLogros cambia el orden de los logros del registro. El body trae los
+// ids de todos sus logros en el orden nuevo; el primero pasa a ser el logro
+// principal. Exige If-Match con el ETag del registro.
+func (app *application) reorderLogros(w http.ResponseWriter, r *http.Request) {
+	existing, ok := app.loadRegistro(w, r, policy.UpdateRegistro, "No tienes permiso para editar este registro")
+	if !ok {
+		return
+	}
+
+	if !app.checkIfMatch(w, r, existing) {
+		return
+	}
+
+	var form logroReorderForm
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	current := make([]int, 0, len(existing.Logros))
+	for _, logro := range existing.Logros {
+		current = append(current, logro.ID_Logro)
+	}
+	requested := slices.Clone(form.Logros)
+	slices.Sort(current)
+	slices.Sort(requested)
+	form.CheckField(slices.Equal(current, requested), "logros", "Debe incluir cada logro del registro exactamente una vez")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	id := existing.Registro.ID_Registro
+	err = app.uow.WithTx(func(repos models.Repos) error {
+		err := repos.Registros.Touch(id, existing.Registro.Version)
+		if err != nil {
+			return err
+		}
+		return repos.Logros.Reorder(id, form.Logros)
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrEditConflict) {
+			app.editConflict(w, r, id)
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.writeRegistro(w, r, id, "Logros reordenados exitosamente")
+}
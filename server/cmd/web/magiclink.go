@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"crud-web/internal/validator"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type magicLinkForm struct {
+	Email               string `json:"email"`
+	validator.Validator `json:"-"`
+}
+
+// requestMagicLink envía al email un link de login de un solo uso. Cada link
+// nuevo invalida los anteriores del mismo usuario. Igual que forgotPassword, la
+// respuesta no revela si el email está registrado. Cada pedido cuenta como un
+// intento de login sin terminar en los mismos límites por email y por IP que
+// login, así que no se pueden mandar correos sin límite a una dirección.
+func (app *application) requestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var form magicLinkForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Email), "email", "Este campo no puede estar en blanco")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "Email inválido")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	if app.loginBlocked(w, r, form.Email) {
+		return
+	}
+	app.loginFailed(r, form.Email, "magic_link_requested")
+
+	user, err := app.users.GetByEmail(form.Email)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if err == nil && !user.Deshabilitado {
+		err = app.tokens.DeleteAllForUser(models.ScopeMagicLink, user.ID)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		token, hash, err := generateOpaqueToken()
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		err = app.tokens.Insert(user.ID, models.ScopeMagicLink, hash, time.Now().Add(app.magicLinkTTL))
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		link := fmt.Sprintf("%s/login/magic/callback?token=%s", app.baseURL, url.QueryEscape(token))
+		body := fmt.Sprintf("Hola %s,\n\nUsa el siguiente link para iniciar sesión. "+
+			"Funciona una sola vez y expira en %d minutos:\n\n%s\n\n"+
+			"Si no fuiste tú, puedes ignorar este correo.\n",
+			user.Nombre, int(app.magicLinkTTL.Minutes()), link)
+
+		app.background(func() {
+			err := app.mailer.Send(user.Email, "Tu link para iniciar sesión", body)
+			if err != nil {
+				app.logger.Error(err.Error(), "user_id", user.ID)
+			}
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Si el email está registrado, recibirás un link para iniciar sesión",
+	})
+}
+
+// magicLinkCallback canjea el token del link y regresa la misma sesión que
+// login. Abrir el link demuestra que el usuario controla el email, así que
+// también lo marca como verificado. Si la cuenta tiene 2FA se pide el código.
+func (app *application) magicLinkCallback(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		app.writeJSON(w, map[string]string{
+			"error": "Token requerido",
+		})
+		return
+	}
+
+	userID, err := app.tokens.Consume(models.ScopeMagicLink, hashOpaqueToken(token))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidToken) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			app.writeJSON(w, map[string]string{
+				"error": "El link es inválido o ya expiró",
+			})
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	user, err := app.users.Get(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if !user.Verificado {
+		err = app.users.SetVerified(user.ID)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+		user.Verificado = true
+	}
+
+	app.loginSucceeded(r, user.Email)
+
+	app.completeLogin(w, r, user)
+}
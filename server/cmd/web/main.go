@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
+	"crud-web/internal/database"
+	"crud-web/internal/jwtkeys"
+	"crud-web/internal/mailer"
 	"crud-web/internal/models"
-	"database/sql"
+	"crud-web/internal/search"
+	"crud-web/internal/throttle"
 	"flag"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
+	"time"
 
 	"github.com/go-playground/form/v4"
-	"github.com/go-sql-driver/mysql"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
 )
@@ -20,8 +26,40 @@ type application struct {
 	users *models.UsersModel
     registros *models.RegistrosModel
     logros *models.LogrosModel
+    uow *models.UnitOfWork
+    refreshTokens *models.RefreshTokensModel
+    tokens *models.TokensModel
+    recoveryCodes *models.RecoveryCodesModel
+    apiTokens *models.APITokensModel
+    identities *models.IdentitiesModel
+    revisions *models.LogroRevisionsModel
+    oidc *oidcClient
+    search search.Index
+    mailer mailer.Mailer
+    emailLimiter *throttle.Limiter
+    ipLimiter *throttle.Limiter
 	formDecoder *form.Decoder
-    jwtSecret string
+    jwtKeys *jwtkeys.Keyring
+    accessTokenTTL time.Duration
+    refreshTokenTTL time.Duration
+    passwordResetTTL time.Duration
+    emailVerificationTTL time.Duration
+    mfaPendingTTL time.Duration
+    magicLinkLogin bool
+    magicLinkTTL time.Duration
+    totpIssuer string
+    requireVerifiedEmail bool
+    sessionCookies bool
+    cookieSecure bool
+    clientURL string
+    maxPageSize int
+    weekMaxDays int
+    weekMaxFutureDays int
+    weekAlign bool
+    weekStart time.Weekday
+    duplicateWeeks string
+    trashRetention time.Duration
+    baseURL string
 }
 
 func main(){
@@ -30,14 +68,139 @@ func main(){
         log.Fatal("Error loading .env file")
     }
 	addr := flag.String("addr", ":4000", "HTTP network address")
+	accessTokenTTL := flag.Duration("access-token-ttl", 15*time.Minute, "Vigencia de los access tokens (JWT)")
+	refreshTokenTTL := flag.Duration("refresh-token-ttl", 30*24*time.Hour, "Vigencia de los refresh tokens")
+	passwordResetTTL := flag.Duration("password-reset-ttl", time.Hour, "Vigencia de los tokens de reset de contraseña")
+	emailVerificationTTL := flag.Duration("email-verification-ttl", 24*time.Hour, "Vigencia de los links de verificación de email")
+	requireVerifiedEmail := flag.Bool("require-verified-email", false, "Bloquear la creación de registros hasta que el usuario verifique su email")
+	mfaPendingTTL := flag.Duration("mfa-pending-ttl", 5*time.Minute, "Tiempo para ingresar el código 2FA después de la contraseña")
+	magicLinkLogin := flag.Bool("magic-link-login", false, "Permitir login sin contraseña con un link enviado por email")
+	magicLinkTTL := flag.Duration("magic-link-ttl", 15*time.Minute, "Vigencia de los links de login por email")
+	totpIssuer := flag.String("totp-issuer", "Registro de Logros", "Nombre que muestran las apps autenticadoras")
+	throttleStore := flag.String("login-throttle-store", "memory", "Dónde guardar los intentos fallidos de login: memory o mysql")
+	maxFailuresEmail := flag.Int("login-max-failures-email", 5, "Fallos de login por email antes de bloquear")
+	maxFailuresIP := flag.Int("login-max-failures-ip", 20, "Fallos de login por IP antes de bloquear")
+	jwtKeysDir := flag.String("jwt-keys-dir", "", "Directorio con llaves *.pem (RS256/EdDSA) para firmar JWT; vacío usa HS256 con JWTSECRET")
+	jwtSigningKID := flag.String("jwt-signing-kid", "", "kid (nombre del archivo sin .pem) de la llave que firma los tokens nuevos")
+	sessionCookies := flag.Bool("session-cookies", false, "Entregar la sesión en cookies HttpOnly (con protección CSRF) en lugar del body")
+	cookieSecure := flag.Bool("cookie-secure", true, "Marcar las cookies de sesión como Secure (solo HTTPS)")
+	baseURL := flag.String("base-url", "http://localhost:4000", "URL pública de esta API, usada en los links de los correos")
+	clientURL := flag.String("client-url", "http://localhost:3000", "URL base del cliente web, usada en los links de los correos")
+	oidcIssuer := flag.String("oidc-issuer", "", "Issuer del proveedor OIDC para login SSO; vacío lo deshabilita")
+	oidcClientID := flag.String("oidc-client-id", "", "Client ID registrado en el proveedor OIDC")
+	oidcRedirectURL := flag.String("oidc-redirect-url", "", "Redirect URI registrada en el proveedor; vacío usa base-url + /oidc/callback")
+	maxPageSize := flag.Int("max-page-size", 100, "Máximo de registros por página en GET /registros")
+	weekMaxDays := flag.Int("week-max-days", 7, "Máximo de días entre inicio_semana y fin_semana (inclusive)")
+	weekMaxFutureDays := flag.Int("week-max-future-days", 7, "Cuántos días en el futuro puede empezar una semana")
+	weekAlign := flag.Bool("week-align", false, "Exigir que cada semana empiece en -week-start y dure exactamente 7 días")
+	weekStartName := flag.String("week-start", "monday", "Día en que empiezan las semanas (monday, sunday, ...)")
+	duplicateWeeks := flag.String("duplicate-weeks", models.DuplicateAllow, "Qué hacer al crear un registro en una semana que ya tiene uno: reject, allow o merge")
+	trashRetention := flag.Duration("trash-retention", 30*24*time.Hour, "Tiempo que un registro eliminado permanece en la papelera antes de purgarse")
+	trashPurgeInterval := flag.Duration("trash-purge-interval", time.Hour, "Cada cuánto se purgan los registros vencidos de la papelera")
+	searchBackend := flag.String("search-backend", "mysql", "Índice para GET /search: mysql (FULLTEXT) o memory (no usa MySQL; se llena con cada escritura)")
+	searchFixture := flag.String("search-fixture", "", "Archivo JSON con documentos para cargar el índice memory al arrancar")
+	mailerKind := flag.String("mailer", "log", "Cómo enviar correos: log (stdout) o smtp")
+	smtpAddr := flag.String("smtp-addr", "localhost:1025", "Dirección del servidor SMTP")
+	smtpSender := flag.String("smtp-sender", "Registro de Logros <no-reply@localhost>", "Remitente de los correos")
 	flag.Parse()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-    db, err := openDB()
+
+	weekStart, err := parseWeekday(*weekStartName)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	if !slices.Contains(models.DuplicatePolicies, *duplicateWeeks) {
+		logger.Error("unknown duplicate weeks policy", "policy", *duplicateWeeks)
+		os.Exit(1)
+	}
+
+	var mail mailer.Mailer
+	switch *mailerKind {
+	case "log":
+		mail = &mailer.LogMailer{Logger: logger}
+	case "smtp":
+		mail = &mailer.SMTPMailer{
+			Addr:     *smtpAddr,
+			Sender:   *smtpSender,
+			Username: os.Getenv("SMTPUSER"),
+			Password: os.Getenv("SMTPPASS"),
+		}
+	default:
+		logger.Error("unknown mailer", "mailer", *mailerKind)
+		os.Exit(1)
+	}
+	var keys *jwtkeys.Keyring
+	if *jwtKeysDir == "" {
+		keys, err = jwtkeys.NewHMAC([]byte(os.Getenv("JWTSECRET")))
+	} else {
+		keys, err = jwtkeys.LoadDir(*jwtKeysDir, *jwtSigningKID, []byte(os.Getenv("JWTSECRET")))
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	var sso *oidcClient
+	if *oidcIssuer != "" {
+		redirectURL := *oidcRedirectURL
+		if redirectURL == "" {
+			redirectURL = *baseURL + "/oidc/callback"
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		sso, err = newOIDCClient(ctx, *oidcIssuer, *oidcClientID, os.Getenv("OIDC_CLIENT_SECRET"), redirectURL)
+		cancel()
+		if err != nil {
+			logger.Error("oidc discovery failed", "issuer", *oidcIssuer, "error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+    db, err := database.Open()
     if err != nil {
         logger.Error(err.Error())
         os.Exit(1)
     }
     defer db.Close()
+
+	var attempts throttle.Store
+	switch *throttleStore {
+	case "memory":
+		attempts = throttle.NewMemoryStore()
+	case "mysql":
+		attempts = &models.LoginAttemptsModel{DB: db}
+	default:
+		logger.Error("unknown login throttle store", "store", *throttleStore)
+		os.Exit(1)
+	}
+	lockout := throttle.Policy{
+		BaseDelay: 30 * time.Second,
+		MaxDelay:  time.Hour,
+		Window:    time.Hour,
+	}
+	emailPolicy, ipPolicy := lockout, lockout
+	emailPolicy.MaxFailures = *maxFailuresEmail
+	ipPolicy.MaxFailures = *maxFailuresIP
+
+	var index search.Index
+	switch *searchBackend {
+	case "mysql":
+		index = &models.SearchModel{DB: db}
+	case "memory":
+		memory := search.NewMemoryIndex()
+		if *searchFixture != "" {
+			err := loadSearchFixture(memory, *searchFixture)
+			if err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+		}
+		index = memory
+	default:
+		logger.Error("unknown search backend", "backend", *searchBackend)
+		os.Exit(1)
+	}
+
 	formDecoder := form.NewDecoder()
 	app := application {
 		logger: logger,
@@ -45,35 +208,46 @@ func main(){
 		users: &models.UsersModel{DB: db},
         registros: &models.RegistrosModel{DB: db},
         logros: &models.LogrosModel{DB: db},
-        jwtSecret: os.Getenv("JWTSECRET"),
+        uow: &models.UnitOfWork{DB: db},
+        refreshTokens: &models.RefreshTokensModel{DB: db},
+        tokens: &models.TokensModel{DB: db},
+        recoveryCodes: &models.RecoveryCodesModel{DB: db},
+        apiTokens: &models.APITokensModel{DB: db},
+        identities: &models.IdentitiesModel{DB: db},
+        revisions: &models.LogroRevisionsModel{DB: db},
+        oidc: sso,
+        search: index,
+        mailer: mail,
+        emailLimiter: &throttle.Limiter{Store: attempts, Policy: emailPolicy},
+        ipLimiter: &throttle.Limiter{Store: attempts, Policy: ipPolicy},
+        jwtKeys: keys,
+        accessTokenTTL: *accessTokenTTL,
+        refreshTokenTTL: *refreshTokenTTL,
+        passwordResetTTL: *passwordResetTTL,
+        emailVerificationTTL: *emailVerificationTTL,
+        mfaPendingTTL: *mfaPendingTTL,
+        magicLinkLogin: *magicLinkLogin,
+        magicLinkTTL: *magicLinkTTL,
+        totpIssuer: *totpIssuer,
+        requireVerifiedEmail: *requireVerifiedEmail,
+        sessionCookies: *sessionCookies,
+        cookieSecure: *cookieSecure,
+        clientURL: *clientURL,
+        maxPageSize: *maxPageSize,
+        weekMaxDays: *weekMaxDays,
+        weekMaxFutureDays: *weekMaxFutureDays,
+        weekAlign: *weekAlign,
+        weekStart: weekStart,
+        duplicateWeeks: *duplicateWeeks,
+        trashRetention: *trashRetention,
+        baseURL: *baseURL,
 	}
+	app.background(func() {
+		app.purgeTrash(*trashPurgeInterval)
+	})
+
 	logger.Info("starting server", "addr", addr)
 	 err = http.ListenAndServe(*addr, app.routes())
     logger.Error(err.Error())
     os.Exit(1)
 }
-
-// openDB inicia la conexión a la base de datos
-// para realizar las operaciones de los modelos
-func openDB() (*sql.DB, error) {
-    cfg := mysql.NewConfig()
-    cfg.User = os.Getenv("DBUSER")
-    cfg.Passwd = os.Getenv("DBPASS")
-    cfg.Net = "tcp"
-    cfg.Addr = os.Getenv("DBADDR")
-    cfg.DBName = "railway" 
-    cfg.ParseTime = true 
-    
-    db, err := sql.Open("mysql", cfg.FormatDSN())
-    if err != nil {
-        return nil, err
-    }
-    
-    err = db.Ping()
-    if err != nil {
-        db.Close()
-        return nil, err
-    }
-
-    return db, nil
-}
\ No newline at end of file
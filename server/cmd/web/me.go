@@ -0,0 +1,276 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"crud-web/internal/validator"
+	"errors"
+	"net/http"
+)
+
+type profileUpdateForm struct {
+	Nombre              *string `json:"nombre"`
+	Apellido            *string `json:"apellido"`
+	Email               *string `json:"email"`
+	validator.Validator `json:"-"`
+}
+
+type passwordChangeForm struct {
+	CurrentPassword     string `json:"current_password"`
+	NewPassword         string `json:"new_password"`
+	validator.Validator `json:"-"`
+}
+
+type accountDeleteForm struct {
+	Password            string `json:"password"`
+	validator.Validator `json:"-"`
+}
+
+// currentUser obtiene el usuario autenticado. Si ya no existe (por ejemplo, se
+// eliminó la cuenta con un access token todavía vigente) responde 401 y regresa
+// false.
+func (app *application) currentUser(w http.ResponseWriter, r *http.Request) (models.User, bool) {
+	user, err := app.users.Get(getUserID(r))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			app.writeJSON(w, map[string]string{
+				"error": "Invalid token",
+			})
+			return models.User{}, false
+		}
+		app.serverError(w, r, err)
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// viewMe regresa los datos del usuario autenticado
+func (app *application) viewMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"user": user,
+	})
+}
+
+// updateMe actualiza nombre, apellido y/o email del usuario autenticado. Solo
+// se validan y cambian los campos presentes en el body. Si cambia el email, la
+// cuenta vuelve a quedar sin verificar y se envía un correo de verificación a
+// la dirección nueva.
+func (app *application) updateMe(w http.ResponseWriter, r *http.Request) {
+	var form profileUpdateForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	user, ok := app.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if form.Nombre != nil {
+		form.CheckField(validator.NotBlank(*form.Nombre), "nombre", "Este campo no puede estar en blanco")
+		form.CheckField(validator.MaxChars(*form.Nombre, 100), "nombre", "Este campo no puede tener más de 100 caracteres")
+	}
+	if form.Apellido != nil {
+		form.CheckField(validator.NotBlank(*form.Apellido), "apellido", "Este campo no puede estar en blanco")
+	}
+	if form.Email != nil {
+		form.CheckField(validator.NotBlank(*form.Email), "email", "Este campo no puede estar en blanco")
+		form.CheckField(validator.Matches(*form.Email, validator.EmailRX), "email", "Email inválido")
+	}
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	if form.Nombre != nil {
+		user.Nombre = *form.Nombre
+	}
+	if form.Apellido != nil {
+		user.Apellido = *form.Apellido
+	}
+
+	emailChanged := form.Email != nil && *form.Email != user.Email
+	if emailChanged {
+		_, err = app.users.GetByEmail(*form.Email)
+		if err == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			app.writeJSON(w, map[string]string{
+				"error": "El email ya está registrado",
+			})
+			return
+		}
+		if !errors.Is(err, models.ErrNoRecord) {
+			app.serverError(w, r, err)
+			return
+		}
+
+		user.Email = *form.Email
+		user.Verificado = false
+	}
+
+	err = app.users.UpdateProfile(user.ID, user.Nombre, user.Apellido, user.Email, user.Verificado)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if emailChanged {
+		err = app.tokens.DeleteAllForUser(models.ScopeEmailVerification, user.ID)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		err = app.sendVerificationEmail(user)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Perfil actualizado exitosamente",
+		"user":    user,
+	})
+}
+
+// changeMyPassword cambia la contraseña del usuario autenticado después de
+// verificar la actual. Cierra todas las sesiones del usuario y regresa una
+// sesión nueva para el cliente que hizo el cambio.
+func (app *application) changeMyPassword(w http.ResponseWriter, r *http.Request) {
+	var form passwordChangeForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.CurrentPassword), "current_password", "Este campo no puede estar en blanco")
+	form.CheckField(validator.NotBlank(form.NewPassword), "new_password", "Este campo no puede estar en blanco")
+	form.CheckField(validator.MinChars(form.NewPassword, 6), "new_password", "La contraseña debe tener al menos 6 caracteres")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	user, ok := app.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !checkPassword(form.CurrentPassword, user.Password) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": map[string]string{"current_password": "La contraseña actual es incorrecta"},
+		})
+		return
+	}
+
+	hashedPassword, err := hashPassword(form.NewPassword)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.users.UpdatePassword(user.ID, hashedPassword)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.refreshTokens.RevokeAllForUser(user.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	session, err := app.newSession(user)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	session["message"] = "Contraseña actualizada exitosamente"
+
+	app.writeSession(w, r, session)
+}
+
+// deleteMe elimina la cuenta del usuario autenticado junto con todos sus
+// registros y logros. Exige la contraseña para confirmar.
+func (app *application) deleteMe(w http.ResponseWriter, r *http.Request) {
+	var form accountDeleteForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Password), "password", "Este campo no puede estar en blanco")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	user, ok := app.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !checkPassword(form.Password, user.Password) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": map[string]string{"password": "La contraseña es incorrecta"},
+		})
+		return
+	}
+
+	err = app.users.Delete(user.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.unindexUser(user.ID)
+	app.logger.Info("account deleted", "user_id", user.ID)
+	app.clearSessionCookies(w)
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Cuenta eliminada exitosamente",
+	})
+}
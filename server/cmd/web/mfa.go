@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bytes"
+	"crud-web/internal/models"
+	"crud-web/internal/validator"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+
+// totpPeriod es la duración de cada paso de tiempo TOTP en segundos
+const totpPeriod = 30
+
+const recoveryCodeCount = 10
+
+type totpCodeForm struct {
+	Code                string `json:"code"`
+	validator.Validator `json:"-"`
+}
+
+type totpDisableForm struct {
+	Password            string `json:"password"`
+	Code                string `json:"code"`
+	validator.Validator `json:"-"`
+}
+
+type loginMFAForm struct {
+	MFAToken            string `json:"mfa_token"`
+	Code                string `json:"code"`
+	RecoveryCode        string `json:"recovery_code"`
+	validator.Validator `json:"-"`
+}
+
+// verifyTOTP valida un código TOTP de 6 dígitos contra el secreto, aceptando un
+// paso de tolerancia hacia atrás y hacia adelante por diferencias de reloj.
+// Regresa el paso de tiempo con el que coincidió el código.
+func verifyTOTP(secret, code string, now time.Time) (int64, bool) {
+	for skew := -1; skew <= 1; skew++ {
+		t := now.Add(time.Duration(skew*totpPeriod) * time.Second)
+		expected, err := totp.GenerateCode(secret, t)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return t.Unix() / totpPeriod, true
+		}
+	}
+	return 0, false
+}
+
+// checkTOTP valida el código del usuario y registra el paso de tiempo usado, de
+// modo que el mismo código no pueda presentarse dos veces
+func (app *application) checkTOTP(user models.User, code string) (bool, error) {
+	step, ok := verifyTOTP(user.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	err := app.users.UseTOTPStep(user.ID, step)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidToken) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// normalizeRecoveryCode quita guiones y espacios y pasa el código a minúsculas,
+// para que el usuario pueda escribirlo con o sin el formato en que se mostró
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// generateRecoveryCodes genera los códigos de recuperación en claro (para
+// mostrarlos una sola vez) y sus hashes (para guardarlos)
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		b := make([]byte, 8)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(b))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashOpaqueToken(code))
+	}
+	return codes, hashes, nil
+}
+
+// writeMFAChallenge responde al primer paso del login de un usuario con 2FA:
+// en lugar de la sesión regresa un token "mfa_pending" opaco, de corta duración
+// y de un solo uso, que solo sirve para /login/mfa.
+func (app *application) writeMFAChallenge(w http.ResponseWriter, r *http.Request, user models.User) {
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.tokens.Insert(user.ID, models.ScopeMFAPending, tokenHash, time.Now().Add(app.mfaPendingTTL))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message":      "Se requiere el código de verificación",
+		"mfa_required": true,
+		"mfa_token":    token,
+		"expires_in":   int(app.mfaPendingTTL.Seconds()),
+	})
+}
+
+// loginMFA completa el login de un usuario con 2FA. Recibe el token
+// "mfa_pending" del primer paso junto con un código TOTP o un código de
+// recuperación, y regresa la misma sesión que login. El token se gasta en el
+// primer intento, acierte o no: cada código que se prueba cuesta volver a
+// pasar por login (y su límite de intentos).
+func (app *application) loginMFA(w http.ResponseWriter, r *http.Request) {
+	var form loginMFAForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.MFAToken), "mfa_token", "Este campo no puede estar en blanco")
+	form.CheckField(validator.NotBlank(form.Code) || validator.NotBlank(form.RecoveryCode), "code", "Ingresa un código de verificación o de recuperación")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	userID, err := app.tokens.Consume(models.ScopeMFAPending, hashOpaqueToken(form.MFAToken))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidToken) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			app.writeJSON(w, map[string]string{
+				"error": "Token inválido",
+			})
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	user, err := app.users.Get(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if app.loginBlocked(w, r, user.Email) {
+		return
+	}
+
+	mfaFailed := func() {
+		app.loginFailed(r, user.Email, "bad_mfa_code")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		app.writeJSON(w, map[string]string{
+			"error": "Código inválido, inicia sesión de nuevo",
+		})
+	}
+
+	// Si el 2FA se deshabilitó después del primer paso no se gasta ningún
+	// código de recuperación.
+	if !user.TOTPHabilitado {
+		mfaFailed()
+		return
+	}
+
+	ok := false
+	if form.RecoveryCode != "" {
+		err = app.recoveryCodes.Consume(user.ID, hashOpaqueToken(normalizeRecoveryCode(form.RecoveryCode)))
+		if err != nil && !errors.Is(err, models.ErrInvalidToken) {
+			app.serverError(w, r, err)
+			return
+		}
+		ok = err == nil
+		if ok {
+			app.logger.Info("recovery code used", "user_id", user.ID)
+		}
+	} else {
+		ok, err = app.checkTOTP(user, form.Code)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	if !ok {
+		mfaFailed()
+		return
+	}
+
+	app.loginSucceeded(r, user.Email)
+
+	if user.Deshabilitado {
+		app.accountDisabled(w)
+		return
+	}
+
+	session, err := app.newSession(user)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	session["message"] = "Login exitoso"
+
+	app.writeSession(w, r, session)
+}
+
+// enrollTOTP genera un secreto TOTP nuevo para el usuario autenticado y lo
+// guarda como pendiente. Regresa el URI otpauth:// y el código QR en PNG
+// (base64) para escanearlo con una app autenticadora. El 2FA no se activa
+// hasta confirmarlo con confirmTOTP.
+func (app *application) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	user, err := app.users.Get(getUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if user.TOTPHabilitado {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		app.writeJSON(w, map[string]string{
+			"error": "El 2FA ya está habilitado",
+		})
+		return
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      app.totpIssuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.users.SetTOTPSecret(user.ID, key.Secret())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"secret":      key.Secret(),
+		"otpauth_uri": key.URL(),
+		"qr_png":      "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+}
+
+// confirmTOTP activa el 2FA una vez que el usuario demuestra, con un código
+// válido, que su app autenticadora quedó configurada. Genera los códigos de
+// recuperación y los regresa en claro; es la única vez que se muestran.
+func (app *application) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var form totpCodeForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Code), "code", "Este campo no puede estar en blanco")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	user, err := app.users.Get(getUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if user.TOTPHabilitado || user.TOTPSecret == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		app.writeJSON(w, map[string]string{
+			"error": "No hay una configuración de 2FA pendiente",
+		})
+		return
+	}
+
+	ok, err := app.checkTOTP(user, form.Code)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": map[string]string{"code": "Código inválido"},
+		})
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.recoveryCodes.Replace(user.ID, hashes)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.users.EnableTOTP(user.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.logger.Info("totp enabled", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message":        "2FA habilitado exitosamente",
+		"recovery_codes": codes,
+	})
+}
+
+// disableTOTP desactiva el 2FA del usuario autenticado. Exige la contraseña y
+// un código TOTP vigente para que un access token robado no baste para quitarlo.
+func (app *application) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	var form totpDisableForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Password), "password", "Este campo no puede estar en blanco")
+	form.CheckField(validator.NotBlank(form.Code), "code", "Este campo no puede estar en blanco")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	user, err := app.users.Get(getUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if !user.TOTPHabilitado {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		app.writeJSON(w, map[string]string{
+			"error": "El 2FA no está habilitado",
+		})
+		return
+	}
+
+	ok := checkPassword(form.Password, user.Password)
+	if ok {
+		ok, err = app.checkTOTP(user, form.Code)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		app.writeJSON(w, map[string]string{
+			"error": "Credenciales inválidas",
+		})
+		return
+	}
+
+	err = app.users.DisableTOTP(user.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.recoveryCodes.DeleteAll(user.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.logger.Info("totp disabled", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message": "2FA deshabilitado",
+	})
+}
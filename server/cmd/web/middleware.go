@@ -29,9 +29,10 @@ func commonHeaders(next http.Handler) http.Handler {
 func enableCORS(next http.Handler) http.Handler {
     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
         w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-        w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
-        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+        w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-CSRF-Token, If-None-Match, If-Modified-Since, If-Match")
         w.Header().Set("Access-Control-Allow-Credentials", "true")
+        w.Header().Set("Access-Control-Expose-Headers", "Retry-After, ETag, Last-Modified")
         
         if r.Method == "OPTIONS" {
             w.WriteHeader(http.StatusOK)
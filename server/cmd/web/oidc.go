@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"crud-web/internal/models"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const oidcCookieName = "cw_oidc"
+
+// oidcClient agrupa la configuración de un proveedor OIDC ya descubierto
+type oidcClient struct {
+	issuer   string
+	config   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// oidcClaims son los claims del ID token que usa la aplicación
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+}
+
+// newOIDCClient descubre la configuración del proveedor a partir de su issuer
+// (/.well-known/openid-configuration) y prepara el cliente OAuth2. Funciona con
+// cualquier proveedor que siga el estándar, incluido un issuer de prueba local.
+func newOIDCClient(ctx context.Context, issuer, clientID, clientSecret, redirectURL string) (*oidcClient, error) {
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oidcClient{
+		issuer: issuer,
+		config: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: clientID}),
+	}, nil
+}
+
+// oidcLogin inicia el flujo authorization code + PKCE: genera state, nonce y el
+// code verifier, los guarda en una cookie HttpOnly de corta duración y redirige
+// al proveedor.
+func (app *application) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	state, _, err := generateOpaqueToken()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	nonce, _, err := generateOpaqueToken()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	verifier := oauth2.GenerateVerifier()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    strings.Join([]string{state, nonce, verifier}, "."),
+		Path:     "/oidc",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   app.cookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	url := app.oidc.config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// oidcCallback recibe la respuesta del proveedor. Verifica el state contra la
+// cookie, canjea el código con el code verifier, valida el ID token (firma,
+// issuer, audiencia, expiración y nonce) y vincula la identidad externa con un
+// usuario local. Termina emitiendo la misma sesión que login.
+func (app *application) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	oidcFailed := func(message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		app.writeJSON(w, map[string]string{
+			"error": message,
+		})
+	}
+
+	cookie, err := r.Cookie(oidcCookieName)
+	http.SetCookie(w, &http.Cookie{Name: oidcCookieName, Path: "/oidc", MaxAge: -1, HttpOnly: true, Secure: app.cookieSecure})
+	if err != nil {
+		oidcFailed("La sesión de login expiró, intenta de nuevo")
+		return
+	}
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		oidcFailed("La sesión de login expiró, intenta de nuevo")
+		return
+	}
+	state, nonce, verifier := parts[0], parts[1], parts[2]
+
+	if subtle.ConstantTimeCompare([]byte(state), []byte(r.URL.Query().Get("state"))) != 1 {
+		oidcFailed("State inválido")
+		return
+	}
+
+	if errParam := r.URL.Query().Get("error"); errParam != "" {
+		app.logger.Warn("oidc provider error", "error", errParam, "description", r.URL.Query().Get("error_description"))
+		oidcFailed("El proveedor rechazó el login")
+		return
+	}
+
+	token, err := app.oidc.config.Exchange(r.Context(), r.URL.Query().Get("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		app.logger.Warn("oidc code exchange failed", "error", err.Error())
+		oidcFailed("No se pudo completar el login con el proveedor")
+		return
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		oidcFailed("El proveedor no regresó un ID token")
+		return
+	}
+
+	idToken, err := app.oidc.verifier.Verify(r.Context(), rawIDToken)
+	if err != nil {
+		app.logger.Warn("oidc id token rejected", "error", err.Error())
+		oidcFailed("ID token inválido")
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		oidcFailed("Nonce inválido")
+		return
+	}
+
+	var claims oidcClaims
+	err = idToken.Claims(&claims)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	user, err := app.linkOIDCIdentity(idToken.Subject, claims)
+	if err != nil {
+		if errors.Is(err, errOIDCEmailTaken) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			app.writeJSON(w, map[string]string{
+				"error": "Ya existe una cuenta con ese email. Inicia sesión con tu contraseña",
+			})
+			return
+		}
+		if errors.Is(err, errOIDCNoEmail) {
+			oidcFailed("El proveedor no entregó un email")
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.completeLogin(w, r, user)
+}
+
+var (
+	errOIDCEmailTaken = errors.New("oidc: email belongs to an unlinked account")
+	errOIDCNoEmail    = errors.New("oidc: provider did not return an email")
+)
+
+// linkOIDCIdentity encuentra el usuario vinculado a (issuer, subject). Si no hay
+// vínculo, lo crea: con la cuenta local del mismo email cuando el proveedor
+// asegura que el email está verificado, o con una cuenta nueva si el email no
+// existe. Las cuentas nuevas reciben una contraseña aleatoria que nadie conoce,
+// por lo que solo se puede entrar por SSO (o después de un reset).
+func (app *application) linkOIDCIdentity(subject string, claims oidcClaims) (models.User, error) {
+	userID, err := app.identities.GetUserID(app.oidc.issuer, subject)
+	if err == nil {
+		return app.users.Get(userID)
+	}
+	if !errors.Is(err, models.ErrNoRecord) {
+		return models.User{}, err
+	}
+
+	if claims.Email == "" {
+		return models.User{}, errOIDCNoEmail
+	}
+
+	user, err := app.users.GetByEmail(claims.Email)
+	switch {
+	case err == nil:
+		if !claims.EmailVerified {
+			return models.User{}, errOIDCEmailTaken
+		}
+	case errors.Is(err, models.ErrNoRecord):
+		user, err = app.createOIDCUser(claims)
+		if err != nil {
+			return models.User{}, err
+		}
+	default:
+		return models.User{}, err
+	}
+
+	err = app.identities.Insert(user.ID, app.oidc.issuer, subject, claims.Email)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	app.logger.Info("oidc identity linked", "user_id", user.ID, "issuer", app.oidc.issuer)
+	return user, nil
+}
+
+// createOIDCUser crea la cuenta local para una identidad externa nueva
+func (app *application) createOIDCUser(claims oidcClaims) (models.User, error) {
+	nombre := claims.GivenName
+	if nombre == "" {
+		nombre = claims.Name
+	}
+	if nombre == "" {
+		nombre, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	password, _, err := generateOpaqueToken()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	userID, err := app.users.InsertWithPassword(nombre, claims.FamilyName, claims.Email, hashedPassword)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if claims.EmailVerified {
+		err = app.users.SetVerified(userID)
+		if err != nil {
+			return models.User{}, err
+		}
+	}
+
+	return app.users.Get(userID)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const defaultPageSize = 10
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursorPayload es el contenido de un cursor. Incluye el orden con el que se
+// generó para rechazar cursores usados con otro ?sort=.
+type cursorPayload struct {
+	Orden string `json:"s"`
+	Valor string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// encodeCursor convierte la posición del último registro de una página en un
+// string opaco para el cliente.
+func encodeCursor(orden string, c models.RegistroCursor) string {
+	p := cursorPayload{Orden: orden, Valor: c.InicioSemana.Format("2006-01-02"), ID: c.ID_Registro}
+	if orden == models.SortTitulo {
+		p.Valor = c.Titulo
+	}
+
+	raw, _ := json.Marshal(p)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor hace lo inverso de encodeCursor
+func decodeCursor(s, orden string) (models.RegistroCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return models.RegistroCursor{}, errInvalidCursor
+	}
+
+	var p cursorPayload
+	err = json.Unmarshal(raw, &p)
+	if err != nil || p.Orden != orden || p.ID < 1 {
+		return models.RegistroCursor{}, errInvalidCursor
+	}
+
+	c := models.RegistroCursor{ID_Registro: p.ID}
+	if orden == models.SortTitulo {
+		c.Titulo = p.Valor
+		return c, nil
+	}
+
+	c.InicioSemana, err = time.Parse("2006-01-02", p.Valor)
+	if err != nil {
+		return models.RegistroCursor{}, errInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		orden  string
+		cursor models.RegistroCursor
+	}{
+		{orden: models.SortInicioSemanaDesc, cursor: models.RegistroCursor{InicioSemana: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), ID_Registro: 42}},
+		{orden: models.SortInicioSemanaAsc, cursor: models.RegistroCursor{InicioSemana: time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC), ID_Registro: 1}},
+		{orden: models.SortTitulo, cursor: models.RegistroCursor{Titulo: `Migración "v2" & más`, ID_Registro: 7}},
+		{orden: models.SortTitulo, cursor: models.RegistroCursor{Titulo: "", ID_Registro: 3}},
+	}
+
+	for _, tt := range tests {
+		got, err := decodeCursor(encodeCursor(tt.orden, tt.cursor), tt.orden)
+		if err != nil {
+			t.Errorf("decodeCursor(encodeCursor(%s, %+v)) error: %v", tt.orden, tt.cursor, err)
+			continue
+		}
+		if got != tt.cursor {
+			t.Errorf("decodeCursor(encodeCursor(%s, %+v)) = %+v", tt.orden, tt.cursor, got)
+		}
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	valid := encodeCursor(models.SortInicioSemanaDesc, models.RegistroCursor{InicioSemana: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), ID_Registro: 42})
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+		orden  string
+	}{
+		{name: "used with another sort", cursor: valid, orden: models.SortTitulo},
+		{name: "not base64", cursor: "%%%", orden: models.SortInicioSemanaDesc},
+		{name: "truncated", cursor: valid[:len(valid)-4], orden: models.SortInicioSemanaDesc},
+		{name: "not json", cursor: encode("hola"), orden: models.SortInicioSemanaDesc},
+		{name: "zero id", cursor: encode(`{"s":"-inicio_semana","v":"2026-10-12","id":0}`), orden: models.SortInicioSemanaDesc},
+		{name: "negative id", cursor: encode(`{"s":"-inicio_semana","v":"2026-10-12","id":-5}`), orden: models.SortInicioSemanaDesc},
+		{name: "bad date", cursor: encode(`{"s":"-inicio_semana","v":"2026-13-45","id":1}`), orden: models.SortInicioSemanaDesc},
+		{name: "sql in the value", cursor: encode(`{"s":"-inicio_semana","v":"1' OR '1'='1","id":1}`), orden: models.SortInicioSemanaDesc},
+		{name: "empty", cursor: "", orden: models.SortInicioSemanaDesc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.cursor, tt.orden)
+			if err != errInvalidCursor {
+				t.Errorf("decodeCursor(%q, %s) error = %v; want errInvalidCursor", tt.cursor, tt.orden, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"crud-web/internal/validator"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type forgotPasswordForm struct {
+	Email               string `json:"email"`
+	validator.Validator `json:"-"`
+}
+
+type resetPasswordForm struct {
+	Token               string `json:"token"`
+	Password            string `json:"password"`
+	validator.Validator `json:"-"`
+}
+
+// forgotPassword inicia el flujo de recuperación de contraseña. Si el email
+// pertenece a un usuario se genera un token de un solo uso y se le envía por
+// correo. La respuesta es la misma exista o no el email, para no revelar qué
+// cuentas están registradas.
+func (app *application) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	var form forgotPasswordForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Email), "email", "Este campo no puede estar en blanco")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "Email inválido")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	user, err := app.users.GetByEmail(form.Email)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if err == nil {
+		token, hash, err := generateOpaqueToken()
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		err = app.tokens.Insert(user.ID, models.ScopePasswordReset, hash, time.Now().Add(app.passwordResetTTL))
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		link := fmt.Sprintf("%s/password/reset?token=%s", app.clientURL, url.QueryEscape(token))
+		body := fmt.Sprintf("Hola %s,\n\nRecibimos una solicitud para restablecer tu contraseña. "+
+			"Usa el siguiente link antes de %d minutos:\n\n%s\n\n"+
+			"Si no fuiste tú, puedes ignorar este correo.\n",
+			user.Nombre, int(app.passwordResetTTL.Minutes()), link)
+
+		app.background(func() {
+			err := app.mailer.Send(user.Email, "Restablecer contraseña", body)
+			if err != nil {
+				app.logger.Error(err.Error(), "user_id", user.ID)
+			}
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Si el email está registrado, recibirás un correo con instrucciones",
+	})
+}
+
+// resetPassword canjea un token de reset por una contraseña nueva. El token
+// solo funciona una vez y antes de expirar. Al completar el cambio se cierran
+// todas las sesiones del usuario: se revocan sus refresh tokens, se sube su
+// versión de sesión para que los access tokens ya emitidos dejen de valer, y se
+// eliminan sus tokens de acceso personal.
+func (app *application) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var form resetPasswordForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Token), "token", "Este campo no puede estar en blanco")
+	form.CheckField(validator.NotBlank(form.Password), "password", "Este campo no puede estar en blanco")
+	form.CheckField(validator.MinChars(form.Password, 6), "password", "La contraseña debe tener al menos 6 caracteres")
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	hashedPassword, err := hashPassword(form.Password)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	userID, err := app.tokens.Consume(models.ScopePasswordReset, hashOpaqueToken(form.Token))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidToken) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			app.writeJSON(w, map[string]string{
+				"error": "El token es inválido o ya expiró",
+			})
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.users.UpdatePassword(userID, hashedPassword)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.tokens.DeleteAllForUser(models.ScopePasswordReset, userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.refreshTokens.RevokeAllForUser(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.users.RevokeSessions(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.apiTokens.DeleteAllForUser(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.logger.Info("password reset", "user_id", userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Contraseña actualizada exitosamente",
+	})
+}
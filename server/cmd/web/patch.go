@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"crud-web/internal/models"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	errUnsupportedPatch = errors.New("unsupported patch content type")
+	errPatchTestFailed  = errors.New("json patch test failed")
+)
+
+// registroDocument es la representación editable de un registro, con el
+// titulo y la descripcion de su logro principal. Los patches de
+// PATCH /registros/{id} se aplican sobre ella.
+func registroDocument(r models.RegistroConLogros) registroCreateForm {
+	return registroCreateForm{
+		Titulo:       r.Principal().Titulo,
+		Descripcion:  r.Principal().Descripcion,
+		InicioSemana: r.Registro.InicioSemana.Format("2006-01-02"),
+		FinSemana:    r.Registro.FinSemana.Format("2006-01-02"),
+	}
+}
+
+// applyPatch aplica el body del request sobre original según su Content-Type:
+// JSON Merge Patch (RFC 7396) con application/merge-patch+json o
+// application/json, y JSON Patch (RFC 6902) con application/json-patch+json.
+// El documento resultante no puede tener campos desconocidos.
+func applyPatch(w http.ResponseWriter, r *http.Request, original registroCreateForm) (registroCreateForm, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return registroCreateForm{}, errUnsupportedPatch
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		return registroCreateForm{}, err
+	}
+
+	doc, err := json.Marshal(original)
+	if err != nil {
+		return registroCreateForm{}, err
+	}
+
+	var patched []byte
+	switch mediaType {
+	case contentTypeMergePatch, "application/json":
+		patched, err = jsonpatch.MergePatch(doc, body)
+	case contentTypeJSONPatch:
+		var patch jsonpatch.Patch
+		patch, err = jsonpatch.DecodePatch(body)
+		if err == nil {
+			patched, err = patch.Apply(doc)
+			if errors.Is(err, jsonpatch.ErrTestFailed) {
+				return registroCreateForm{}, errPatchTestFailed
+			}
+		}
+	default:
+		return registroCreateForm{}, errUnsupportedPatch
+	}
+	if err != nil {
+		return registroCreateForm{}, err
+	}
+
+	var form registroCreateForm
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&form)
+	if err != nil {
+		return registroCreateForm{}, err
+	}
+	return form, nil
+}
@@ -10,14 +10,69 @@ func (app *application) routes() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /register", app.register)
 	mux.HandleFunc("POST /login", app.login)
+	mux.HandleFunc("POST /login/mfa", app.loginMFA)
+	mux.HandleFunc("POST /token/refresh", app.refreshToken)
+	mux.HandleFunc("POST /logout", app.logout)
+	mux.HandleFunc("POST /password/forgot", app.forgotPassword)
+	mux.HandleFunc("POST /password/reset", app.resetPassword)
+	mux.HandleFunc("GET /verify", app.verifyEmail)
+	mux.HandleFunc("GET /.well-known/jwks.json", app.jwks)
 
-	mux.Handle("POST /registros", app.requireAuth(http.HandlerFunc(app.createRegistro)))
-	mux.Handle("GET /registros", app.requireAuth(http.HandlerFunc(app.viewRegistro)))
-	mux.Handle("PATCH /registros/{id}", app.requireAuth(http.HandlerFunc(app.editRegistro)))
-	mux.Handle("DELETE /registros/{id}", app.requireAuth(http.HandlerFunc(app.deleteRegistro)))
+	if app.magicLinkLogin {
+		mux.HandleFunc("POST /login/magic", app.requestMagicLink)
+		mux.HandleFunc("GET /login/magic/callback", app.magicLinkCallback)
+	}
+
+	if app.oidc != nil {
+		mux.HandleFunc("GET /oidc/login", app.oidcLogin)
+		mux.HandleFunc("GET /oidc/callback", app.oidcCallback)
+	}
 
 	// Alice es una libreria que sirve para encadenar tus middlewares de HTTP de forma
 	// conveniente
+	protected := alice.New(app.requireAuth)
+	session := protected.Append(app.requireSession)
+	readRegistros := protected.Append(app.requireScope(scopeRegistrosRead))
+	writeRegistros := protected.Append(app.requireScope(scopeRegistrosWrite))
+
+	mux.Handle("POST /verify/resend", session.ThenFunc(app.resendVerification))
+	mux.Handle("POST /mfa/totp/enroll", session.ThenFunc(app.enrollTOTP))
+	mux.Handle("POST /mfa/totp/confirm", session.ThenFunc(app.confirmTOTP))
+	mux.Handle("POST /mfa/totp/disable", session.ThenFunc(app.disableTOTP))
+
+	mux.Handle("GET /me", session.ThenFunc(app.viewMe))
+	mux.Handle("PATCH /me", session.ThenFunc(app.updateMe))
+	mux.Handle("POST /me/password", session.ThenFunc(app.changeMyPassword))
+	mux.Handle("DELETE /me", session.ThenFunc(app.deleteMe))
+
+	mux.Handle("POST /tokens", session.ThenFunc(app.createAPIToken))
+	mux.Handle("GET /tokens", session.ThenFunc(app.listAPITokens))
+	mux.Handle("DELETE /tokens/{id}", session.ThenFunc(app.deleteAPIToken))
+
+	admin := session.Append(app.requireAdmin)
+	mux.Handle("GET /admin/users", admin.ThenFunc(app.adminListUsers))
+	mux.Handle("POST /admin/users/{id}/disable", admin.ThenFunc(app.adminDisableUser))
+	mux.Handle("POST /admin/users/{id}/enable", admin.ThenFunc(app.adminEnableUser))
+	mux.Handle("PATCH /admin/users/{id}/role", admin.ThenFunc(app.adminSetRole))
+
+	mux.Handle("POST /registros", writeRegistros.Append(app.requireVerified).ThenFunc(app.createRegistro))
+	mux.Handle("GET /registros", readRegistros.ThenFunc(app.viewRegistro))
+	mux.Handle("GET /registros/{id}", readRegistros.ThenFunc(app.viewRegistroByID))
+	mux.Handle("GET /registros/trash", readRegistros.ThenFunc(app.viewTrash))
+	mux.Handle("POST /registros/{id}/restore", writeRegistros.ThenFunc(app.restoreRegistro))
+	mux.Handle("GET /registros/{id}/history", readRegistros.ThenFunc(app.viewHistory))
+	mux.Handle("POST /registros/{id}/history/{rev}/revert", writeRegistros.ThenFunc(app.revertRevision))
+	mux.Handle("POST /registros/{id}/logros", writeRegistros.ThenFunc(app.createLogro))
+	mux.Handle("PUT /registros/{id}/logros/order", writeRegistros.ThenFunc(app.reorderLogros))
+	mux.Handle("PATCH /registros/{id}/logros/{logroId}", writeRegistros.ThenFunc(app.updateLogro))
+	mux.Handle("DELETE /registros/{id}/logros/{logroId}", writeRegistros.ThenFunc(app.deleteLogro))
+	mux.Handle("GET /registros/{id}/logros/{logroId}/history", readRegistros.ThenFunc(app.viewHistory))
+	mux.Handle("POST /registros/{id}/logros/{logroId}/history/{rev}/revert", writeRegistros.ThenFunc(app.revertRevision))
+	mux.Handle("GET /search", readRegistros.ThenFunc(app.searchRegistros))
+	mux.Handle("PATCH /registros/{id}", writeRegistros.ThenFunc(app.editRegistro))
+	mux.Handle("PUT /registros/{id}", writeRegistros.ThenFunc(app.replaceRegistro))
+	mux.Handle("DELETE /registros/{id}", writeRegistros.ThenFunc(app.deleteRegistro))
+
 	standard := alice.New(app.recoverPanic, app.logRequest, enableCORS, commonHeaders)
 
     return standard.Then(mux)
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"crud-web/internal/search"
+	"crud-web/internal/validator"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20
+	snippetWidth       = 160
+)
+
+// searchResult es un registro encontrado por /search. Score es el de su logro
+// más relevante; Logros son solo los logros que coinciden, del más relevante al
+// menos.
+type searchResult struct {
+	models.Registro
+	Score  float64       `json:"score"`
+	Logros []searchMatch `json:"logros"`
+}
+
+// searchMatch es un logro que coincide con la búsqueda, con su relevancia y los
+// fragmentos resaltados
+type searchMatch struct {
+	models.Logro
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// searchRegistros busca en los logros del usuario autenticado (?q=) y regresa
+// hasta limit registros ordenados por la relevancia de su mejor logro, cada uno
+// con sus logros que coinciden. Los highlights vienen escapados como HTML, con
+// las coincidencias envueltas en <mark>.
+func (app *application) searchRegistros(w http.ResponseWriter, r *http.Request) {
+	var v validator.Validator
+	query := r.URL.Query()
+
+	q := strings.TrimSpace(query.Get("q"))
+	v.CheckField(validator.NotBlank(q), "q", "Este campo no puede estar en blanco")
+	v.CheckField(validator.MaxChars(q, 100), "q", "La búsqueda no puede tener más de 100 caracteres")
+
+	limit := defaultSearchLimit
+	if query.Has("limit") {
+		var err error
+		limit, err = strconv.Atoi(query.Get("limit"))
+		v.CheckField(err == nil && limit >= 1, "limit", "Debe ser un número entero positivo")
+		v.CheckField(limit <= app.maxPageSize, "limit", fmt.Sprintf("No puede ser mayor a %d", app.maxPageSize))
+	}
+
+	if !v.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": v.FieldErrors,
+		})
+		return
+	}
+
+	hits, err := app.search.Search(getUserID(r), q, limit)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	terms := search.Terms(q)
+	grouped := search.Group(hits)
+	results := make([]searchResult, 0, len(grouped))
+	for _, group := range grouped {
+		result := searchResult{
+			Registro: models.Registro{
+				ID_Registro:  group.RegistroID,
+				ID_Usuario:   group.UserID,
+				InicioSemana: group.InicioSemana,
+				FinSemana:    group.FinSemana,
+			},
+			Score:  group.Score,
+			Logros: make([]searchMatch, 0, len(group.Hits)),
+		}
+		for _, hit := range group.Hits {
+			result.Logros = append(result.Logros, searchMatch{
+				Logro: models.Logro{
+					ID_Logro:    hit.LogroID,
+					Titulo:      hit.Titulo,
+					Descripcion: hit.Descripcion,
+				},
+				Score: hit.Score,
+				Highlights: map[string]string{
+					"titulo":      search.Highlight(hit.Titulo, terms, snippetWidth),
+					"descripcion": search.Highlight(hit.Descripcion, terms, snippetWidth),
+				},
+			})
+		}
+		results = append(results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"results": results,
+	})
+}
+
+// loadSearchFixture carga en index los documentos del archivo JSON path
+func loadSearchFixture(index search.Index, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return search.Load(index, f)
+}
+
+// indexLogro actualiza el índice de búsqueda después de crear o editar un
+// logro. El cambio ya está guardado en la base de datos, así que un error solo
+// se registra en el log.
+func (app *application) indexLogro(doc search.Document) {
+	err := app.search.Upsert(doc)
+	if err != nil {
+		app.logger.Error(err.Error(), "logro_id", doc.LogroID)
+	}
+}
+
+// indexRegistro actualiza en el índice todos los logros del registro, por
+// ejemplo cuando cambia su semana.
+func (app *application) indexRegistro(registro models.RegistroConLogros) {
+	for _, logro := range registro.Logros {
+		app.indexLogro(search.Document{
+			LogroID:      logro.ID_Logro,
+			RegistroID:   registro.Registro.ID_Registro,
+			UserID:       registro.Registro.ID_Usuario,
+			InicioSemana: registro.Registro.InicioSemana,
+			FinSemana:    registro.Registro.FinSemana,
+			Titulo:       logro.Titulo,
+			Descripcion:  logro.Descripcion,
+		})
+	}
+}
+
+// unindexRegistro quita del índice todos los logros del registro
+func (app *application) unindexRegistro(registro models.RegistroConLogros) {
+	for _, logro := range registro.Logros {
+		app.unindexLogro(logro.ID_Logro)
+	}
+}
+
+// unindexLogro quita un logro eliminado del índice de búsqueda
+func (app *application) unindexLogro(logroID int) {
+	err := app.search.Delete(logroID)
+	if err != nil {
+		app.logger.Error(err.Error(), "logro_id", logroID)
+	}
+}
+
+// unindexUser quita del índice todos los logros de una cuenta eliminada
+func (app *application) unindexUser(userID int) {
+	err := app.search.DeleteUser(userID)
+	if err != nil {
+		app.logger.Error(err.Error(), "user_id", userID)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"crud-web/internal/models"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// fakeDB responde las consultas de los modelos con filas fijas, para probar
+// handlers sin MySQL. query recibe el SQL y sus argumentos y regresa las
+// columnas y las filas del resultado.
+type fakeDB struct {
+	query func(stmt string, args []driver.Value) ([]string, [][]driver.Value)
+}
+
+func (d fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{d}, nil }
+func (d fakeDB) Driver() driver.Driver                        { return fakeDriver{d} }
+
+type fakeDriver struct{ db fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d.db}, nil }
+
+type fakeConn struct{ db fakeDB }
+
+func (c fakeConn) Prepare(stmt string) (driver.Stmt, error) { return fakeStmt{c.db, stmt}, nil }
+func (c fakeConn) Close() error                             { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                { return nil, errors.New("fakeDB: transactions not supported") }
+
+type fakeStmt struct {
+	db   fakeDB
+	stmt string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("fakeDB: writes not supported")
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	columns, rows := s.db.query(s.stmt, args)
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// newTestApplication arma una aplicación cuyos modelos leen de db
+func newTestApplication(t *testing.T, db fakeDB) *application {
+	conn := sql.OpenDB(db)
+	t.Cleanup(func() { conn.Close() })
+
+	return &application{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		registros: &models.RegistrosModel{DB: conn},
+	}
+}
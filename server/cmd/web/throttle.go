@@ -0,0 +1,106 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clientIP extrae la IP del cliente de RemoteAddr, sin el puerto
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// loginKeys regresa las llaves con las que se cuentan los fallos de login: una
+// por email (protege cada cuenta) y otra por IP (frena a quien prueba muchas
+// cuentas desde el mismo origen).
+func loginKeys(r *http.Request, email string) (string, string) {
+	return "email:" + strings.ToLower(strings.TrimSpace(email)), "ip:" + clientIP(r)
+}
+
+// loginBlocked verifica si el email o la IP del request están bloqueados. Si lo
+// están, responde 429 con el header Retry-After y regresa true.
+func (app *application) loginBlocked(w http.ResponseWriter, r *http.Request, email string) bool {
+	emailKey, ipKey := loginKeys(r, email)
+	now := time.Now()
+
+	emailWait, err := app.emailLimiter.Check(emailKey, now)
+	if err != nil {
+		app.serverError(w, r, err)
+		return true
+	}
+
+	ipWait, err := app.ipLimiter.Check(ipKey, now)
+	if err != nil {
+		app.serverError(w, r, err)
+		return true
+	}
+
+	wait := max(emailWait, ipWait)
+	if wait == 0 {
+		return false
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	app.logger.Warn("login blocked", "event", "auth.login_blocked", "email", email, "ip", clientIP(r), "retry_after", seconds)
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	app.writeJSON(w, map[string]interface{}{
+		"error":       "Demasiados intentos fallidos, intenta más tarde",
+		"retry_after": seconds,
+	})
+	return true
+}
+
+// loginFailed registra un intento fallido para el email y la IP del request, y
+// emite los eventos de log correspondientes. Los errores del store solo se
+// registran: la respuesta 401 ya está decidida.
+func (app *application) loginFailed(r *http.Request, email, reason string) {
+	emailKey, ipKey := loginKeys(r, email)
+	now := time.Now()
+	ip := clientIP(r)
+
+	rec, emailLock, err := app.emailLimiter.Fail(emailKey, now)
+	if err != nil {
+		app.logger.Error(err.Error(), "event", "auth.throttle_error")
+		return
+	}
+
+	ipRec, ipLock, err := app.ipLimiter.Fail(ipKey, now)
+	if err != nil {
+		app.logger.Error(err.Error(), "event", "auth.throttle_error")
+		return
+	}
+
+	app.logger.Warn("login failed", "event", "auth.login_failed", "reason", reason,
+		"email", email, "ip", ip, "email_failures", rec.Failures, "ip_failures", ipRec.Failures)
+
+	if emailLock > 0 {
+		app.logger.Warn("account locked", "event", "auth.lockout", "scope", "email",
+			"email", email, "ip", ip, "failures", rec.Failures, "duration", emailLock.String())
+	}
+	if ipLock > 0 {
+		app.logger.Warn("ip locked", "event", "auth.lockout", "scope", "ip",
+			"email", email, "ip", ip, "failures", ipRec.Failures, "duration", ipLock.String())
+	}
+}
+
+// loginSucceeded olvida los fallos del email. Los de la IP se conservan, para
+// que entrar a una cuenta propia no reinicie el conteo de quien prueba cuentas
+// ajenas desde la misma IP.
+func (app *application) loginSucceeded(r *http.Request, email string) {
+	emailKey, _ := loginKeys(r, email)
+	err := app.emailLimiter.Succeed(emailKey)
+	if err != nil {
+		app.logger.Error(err.Error(), "event", "auth.throttle_error")
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"crud-web/internal/policy"
+	"crud-web/internal/validator"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiTokenPrefix identifica los tokens de acceso personal, para distinguirlos
+// de un JWT sin tener que parsearlos
+const apiTokenPrefix = "cwpat_"
+
+const (
+	authTypeSession  = "session"
+	authTypeAPIToken = "api_token"
+)
+
+const (
+	scopeRegistrosRead  = "registros:read"
+	scopeRegistrosWrite = "registros:write"
+)
+
+var apiTokenScopes = []string{scopeRegistrosRead, scopeRegistrosWrite}
+
+type apiTokenCreateForm struct {
+	Nombre              string   `json:"nombre"`
+	Scopes              []string `json:"scopes"`
+	ExpiraEn            string   `json:"expira_en"`
+	validator.Validator `json:"-"`
+}
+
+// authenticateAPIToken valida un token de acceso personal y, si es válido,
+// agrega los mismos headers que requireAuth más X-Auth-Scopes con los scopes
+// del token.
+func (app *application) authenticateAPIToken(w http.ResponseWriter, r *http.Request, next http.Handler, tokenString string) {
+	token, err := app.apiTokens.GetByHash(hashOpaqueToken(tokenString))
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if err != nil || (token.ExpiraEn != nil && time.Now().After(*token.ExpiraEn)) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		app.writeJSON(w, map[string]string{
+			"error": "Invalid token",
+		})
+		return
+	}
+
+	user, err := app.users.Get(token.UserID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if user.Deshabilitado {
+		app.accountDisabled(w)
+		return
+	}
+
+	err = app.apiTokens.Touch(token.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	r.Header.Set("X-User-ID", strconv.Itoa(user.ID))
+	r.Header.Set("X-User-Email", user.Email)
+	r.Header.Set("X-User-Role", user.Rol)
+	r.Header.Set("X-Auth-Type", authTypeAPIToken)
+	r.Header.Set("X-Auth-Scopes", strings.Join(token.Scopes, " "))
+
+	next.ServeHTTP(w, r)
+}
+
+// requireScope regresa un middleware que exige el scope indicado a los tokens
+// de acceso personal. Las sesiones normales tienen todos los scopes. Debe ir
+// después de requireAuth.
+func (app *application) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Auth-Type") == authTypeAPIToken &&
+				!slices.Contains(strings.Fields(r.Header.Get("X-Auth-Scopes")), scope) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				app.writeJSON(w, map[string]string{
+					"error": "El token no tiene el scope " + scope,
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireSession es un middleware que solo deja pasar sesiones iniciadas con
+// login, rechazando los tokens de acceso personal. Se usa en rutas que manejan
+// la cuenta, como la creación de más tokens. Debe ir después de requireAuth.
+func (app *application) requireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Type") != authTypeSession {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			app.writeJSON(w, map[string]string{
+				"error": "Esta ruta no acepta tokens de acceso personal",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// createAPIToken crea un token de acceso personal para el usuario autenticado
+// con un nombre, los scopes pedidos y una fecha de expiración opcional. El token
+// en claro se regresa solo en esta respuesta; después solo se guarda su hash.
+func (app *application) createAPIToken(w http.ResponseWriter, r *http.Request) {
+	var form apiTokenCreateForm
+
+	err := app.decodeJSON(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Nombre), "nombre", "Este campo no puede estar en blanco")
+	form.CheckField(validator.MaxChars(form.Nombre, 100), "nombre", "Este campo no puede tener más de 100 caracteres")
+	form.CheckField(len(form.Scopes) > 0, "scopes", "Debes indicar al menos un scope")
+	for _, scope := range form.Scopes {
+		form.CheckField(validator.PermittedValue(scope, apiTokenScopes...), "scopes", "Scope inválido: "+scope)
+	}
+
+	var expiraEn *time.Time
+	if form.ExpiraEn != "" {
+		t, err := time.Parse("2006-01-02", form.ExpiraEn)
+		if err != nil {
+			form.AddFieldError("expira_en", "Formato de fecha inválido (usar YYYY-MM-DD)")
+		} else {
+			form.CheckField(t.After(time.Now()), "expira_en", "La fecha de expiración debe ser futura")
+			expiraEn = &t
+		}
+	}
+
+	if !form.Valid() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		app.writeJSON(w, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": form.FieldErrors,
+		})
+		return
+	}
+
+	secret, _, err := generateOpaqueToken()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	token := apiTokenPrefix + secret
+
+	slices.Sort(form.Scopes)
+	form.Scopes = slices.Compact(form.Scopes)
+
+	id, err := app.apiTokens.Insert(getUserID(r), form.Nombre, hashOpaqueToken(token), form.Scopes, expiraEn)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	apiToken, err := app.apiTokens.Get(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	app.writeJSON(w, map[string]interface{}{
+		"message":   "Token creado exitosamente. Guárdalo, no se volverá a mostrar",
+		"token":     token,
+		"api_token": apiToken,
+	})
+}
+
+// listAPITokens regresa los tokens de acceso personal del usuario autenticado,
+// sin el valor del token
+func (app *application) listAPITokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := app.apiTokens.List(getUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"tokens": tokens,
+	})
+}
+
+// deleteAPIToken revoca un token de acceso personal. Un admin puede revocar
+// los de cualquier usuario.
+func (app *application) deleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		app.writeJSON(w, map[string]string{
+			"error": "ID inválido",
+		})
+		return
+	}
+
+	token, err := app.apiTokens.Get(id)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// A quien no puede revocarlo se le responde igual que si no existiera
+	if err != nil || !policy.Can(getActor(r), policy.RevokeAPIToken, token.UserID) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		app.writeJSON(w, map[string]string{
+			"error": "Token no encontrado",
+		})
+		return
+	}
+
+	err = app.apiTokens.Delete(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Token revocado exitosamente",
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"crud-web/internal/policy"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// trashedRegistroResponse es un registro de la papelera junto con la fecha en
+// que se eliminará definitivamente.
+type trashedRegistroResponse struct {
+	registroResponse
+	PurgaEn time.Time `json:"purga_en"`
+}
+
+// viewTrash regresa los registros del usuario autenticado que están en la
+// papelera.
+func (app *application) viewTrash(w http.ResponseWriter, r *http.Request) {
+	registros, err := app.registros.Trashed(getUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	response := make([]trashedRegistroResponse, 0, len(registros))
+	for _, registro := range registros {
+		response = append(response, trashedRegistroResponse{
+			registroResponse: newRegistroResponse(registro),
+			PurgaEn:          registro.Registro.EliminadoEn.Add(app.trashRetention),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"registros": response,
+	})
+}
+
+// restoreRegistro saca un registro de la papelera. Igual que al editar, exige
+// If-Match con el ETag que el cliente vio en la papelera. Con -duplicate-weeks
+// reject o merge responde 409 si otro registro ya ocupa su semana. Si otro
+// request lo restauró o cambió primero responde 412 con la versión actual.
+func (app *application) restoreRegistro(w http.ResponseWriter, r *http.Request) {
+	existing, ok := app.loadRegistroWith(w, r, app.registros.GetTrashed, policy.UpdateRegistro, "No tienes permiso para restaurar este registro")
+	if !ok {
+		return
+	}
+
+	if !app.checkIfMatch(w, r, existing) {
+		return
+	}
+
+	err := app.uow.WithTx(func(repos models.Repos) error {
+		return repos.Registros.Restore(existing.Registro, app.duplicateWeeks)
+	})
+	if err != nil {
+		var duplicate *models.DuplicateWeekError
+		if errors.As(err, &duplicate) {
+			app.duplicateWeek(w, duplicate)
+			return
+		}
+		if errors.Is(err, models.ErrEditConflict) {
+			app.editConflict(w, r, existing.Registro.ID_Registro)
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	restored := existing
+	restored.Registro.Version++
+	restored.Registro.EliminadoEn = nil
+
+	app.indexRegistro(restored)
+
+	w.Header().Set("ETag", registroETag(restored))
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message":  "Registro restaurado exitosamente",
+		"registro": newRegistroResponse(restored),
+	})
+}
+
+// purgeTrash elimina definitivamente, cada interval, los registros (y con ellos
+// sus logros) que llevan en la papelera más de trashRetention. Corre mientras viva
+// el servidor.
+func (app *application) purgeTrash(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		before := time.Now().Add(-app.trashRetention)
+
+		var purged int
+		err := app.uow.WithTx(func(repos models.Repos) error {
+			var err error
+			purged, err = repos.Registros.PurgeTrashed(before)
+			return err
+		})
+		if err != nil {
+			app.logger.Error("trash purge failed", "error", err.Error())
+			continue
+		}
+		if purged > 0 {
+			app.logger.Info("trash purged", "registros", purged)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestoreRegistroRequiresIfMatch(t *testing.T) {
+	eliminado := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+
+	app := newTestApplication(t, fakeDB{query: func(stmt string, args []driver.Value) ([]string, [][]driver.Value) {
+		switch {
+		case strings.Contains(stmt, "FROM registro r"):
+			return []string{"id_registro", "id_usuario", "inicio_semana", "fin_semana", "version", "eliminado_en", "actualizado_en"}, [][]driver.Value{{
+				int64(1), int64(7), time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+				int64(4), eliminado, eliminado,
+			}}
+		case strings.Contains(stmt, "FROM logro"):
+			return []string{"id_logro", "id_registro", "posicion", "titulo", "descripcion", "version"}, nil
+		}
+		t.Fatalf("unexpected query: %s", stmt)
+		return nil, nil
+	}})
+
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+	}{
+		{name: "missing if-match", wantStatus: http.StatusPreconditionRequired},
+		{name: "stale if-match", ifMatch: `"3"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "weak if-match", ifMatch: `W/"4"`, wantStatus: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/registros/1/restore", nil)
+			r.SetPathValue("id", "1")
+			r.Header.Set("X-User-ID", "7")
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+
+			// app.uow es nil: si el handler llegara a la transacción el test
+			// haría panic en lugar de pasar.
+			app.restoreRegistro(rr, r)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d; want %d (body %s)", rr.Code, tt.wantStatus, rr.Body)
+			}
+		})
+	}
+}
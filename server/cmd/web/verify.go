@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crud-web/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// sendVerificationEmail genera un token de verificación para el usuario y le
+// envía por correo el link para canjearlo. El envío ocurre en segundo plano.
+func (app *application) sendVerificationEmail(user models.User) error {
+	token, hash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	err = app.tokens.Insert(user.ID, models.ScopeEmailVerification, hash, time.Now().Add(app.emailVerificationTTL))
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify?token=%s", app.baseURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Hola %s,\n\nConfirma tu email abriendo el siguiente link:\n\n%s\n\n"+
+		"El link expira en %d horas.\n",
+		user.Nombre, link, int(app.emailVerificationTTL.Hours()))
+
+	app.background(func() {
+		err := app.mailer.Send(user.Email, "Verifica tu email", body)
+		if err != nil {
+			app.logger.Error(err.Error(), "user_id", user.ID)
+		}
+	})
+
+	return nil
+}
+
+// verifyEmail canjea el token de verificación recibido en el query string y
+// marca el email del usuario como verificado. El token solo funciona una vez.
+func (app *application) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		app.writeJSON(w, map[string]string{
+			"error": "Token requerido",
+		})
+		return
+	}
+
+	userID, err := app.tokens.Consume(models.ScopeEmailVerification, hashOpaqueToken(token))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidToken) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			app.writeJSON(w, map[string]string{
+				"error": "El token es inválido o ya expiró",
+			})
+			return
+		}
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.users.SetVerified(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.tokens.DeleteAllForUser(models.ScopeEmailVerification, userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Email verificado exitosamente",
+	})
+}
+
+// resendVerification vuelve a enviar el correo de verificación al usuario
+// autenticado, por ejemplo cuando el link anterior expiró.
+func (app *application) resendVerification(w http.ResponseWriter, r *http.Request) {
+	user, err := app.users.Get(getUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if user.Verificado {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		app.writeJSON(w, map[string]string{
+			"error": "El email ya está verificado",
+		})
+		return
+	}
+
+	err = app.sendVerificationEmail(user)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	app.writeJSON(w, map[string]interface{}{
+		"message": "Correo de verificación enviado",
+	})
+}
+
+// requireVerified es un middleware que bloquea la ruta a usuarios que no han
+// verificado su email, cuando la política -require-verified-email está activa.
+// Debe ir después de requireAuth.
+func (app *application) requireVerified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.requireVerifiedEmail {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := app.users.Get(getUserID(r))
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				app.writeJSON(w, map[string]string{
+					"error": "Invalid token",
+				})
+				return
+			}
+			app.serverError(w, r, err)
+			return
+		}
+
+		if !user.Verificado {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			app.writeJSON(w, map[string]string{
+				"error": "Debes verificar tu email antes de continuar",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
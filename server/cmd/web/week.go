@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crud-web/internal/validator"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdayNames = map[time.Weekday]string{
+	time.Sunday:    "domingo",
+	time.Monday:    "lunes",
+	time.Tuesday:   "martes",
+	time.Wednesday: "miércoles",
+	time.Thursday:  "jueves",
+	time.Friday:    "viernes",
+	time.Saturday:  "sábado",
+}
+
+// parseWeekday acepta el nombre del día en inglés o en español
+func parseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(name)
+	for day, es := range weekdayNames {
+		if name == es || name == strings.ToLower(day.String()) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", name)
+}
+
+// checkWeek valida y convierte las fechas de la semana de un registro. Acepta
+// inicio_semana y fin_semana (YYYY-MM-DD) o iso_week (YYYY-Www), que se
+// expande a las dos fechas. Después revisa que el rango sea una semana según la
+// configuración: en orden, de no más de weekMaxDays días, no demasiado en el
+// futuro y, si weekAlign está activo, de weekStart a seis días después. Los
+// errores se agregan a form.
+func (app *application) checkWeek(form *registroCreateForm) (time.Time, time.Time) {
+	var inicioSemana, finSemana time.Time
+
+	if form.ISOWeek != "" {
+		form.CheckField(form.InicioSemana == "" && form.FinSemana == "", "iso_week", "No se puede combinar con inicio_semana ni fin_semana")
+
+		var ok bool
+		inicioSemana, finSemana, ok = validator.ISOWeek(form.ISOWeek, app.weekStart)
+		form.CheckField(ok, "iso_week", "Semana inválida (usar YYYY-Www, por ejemplo 2026-W42)")
+	} else {
+		form.CheckField(validator.NotBlank(form.InicioSemana), "inicio_semana", "La fecha de inicio no puede estar en blanco")
+		form.CheckField(validator.NotBlank(form.FinSemana), "fin_semana", "La fecha de fin no puede estar en blanco")
+		form.CheckField(validator.Date(form.InicioSemana), "inicio_semana", "Formato de fecha inválido (usar YYYY-MM-DD)")
+		form.CheckField(validator.Date(form.FinSemana), "fin_semana", "Formato de fecha inválido (usar YYYY-MM-DD)")
+
+		inicioSemana, _ = time.Parse("2006-01-02", form.InicioSemana)
+		finSemana, _ = time.Parse("2006-01-02", form.FinSemana)
+	}
+
+	if !form.Valid() {
+		return time.Time{}, time.Time{}
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	form.CheckField(validator.DateOrder(inicioSemana, finSemana), "fin_semana", "La fecha de fin no puede ser anterior a la de inicio")
+	form.CheckField(validator.MaxSpanDays(inicioSemana, finSemana, app.weekMaxDays), "fin_semana",
+		fmt.Sprintf("La semana no puede durar más de %d días", app.weekMaxDays))
+	form.CheckField(validator.NotAfter(inicioSemana, today.AddDate(0, 0, app.weekMaxFutureDays)), "inicio_semana",
+		fmt.Sprintf("La semana no puede empezar más de %d días en el futuro", app.weekMaxFutureDays))
+	if app.weekAlign {
+		form.CheckField(validator.WeekAligned(inicioSemana, finSemana, app.weekStart), "inicio_semana",
+			fmt.Sprintf("La semana debe empezar en %s y terminar seis días después", weekdayNames[app.weekStart]))
+	}
+
+	return inicioSemana, finSemana
+}
@@ -3,14 +3,20 @@ module crud-web
 go 1.24.1
 
 require (
+	github.com/coreos/go-oidc/v3 v3.15.0
+	github.com/evanphx/json-patch/v5 v5.9.11
 	github.com/go-playground/form/v4 v4.2.1
 	github.com/go-sql-driver/mysql v1.9.2
+	github.com/golang-jwt/jwt/v5 v5.2.2
 	github.com/joho/godotenv v1.5.1
 	github.com/justinas/alice v1.2.0
+	github.com/pquerna/otp v1.5.0
+	golang.org/x/crypto v0.38.0
+	golang.org/x/oauth2 v0.30.0
 )
 
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/golang-jwt/jwt/v5 v5.2.2 // indirect
-	golang.org/x/crypto v0.38.0 // indirect
+	github.com/boombuler/barcode v1.0.1-0.20190219062509-6c824513bacc // indirect
+	github.com/go-jose/go-jose/v4 v4.0.5 // indirect
 )
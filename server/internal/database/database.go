@@ -0,0 +1,34 @@
+package database
+
+import (
+	"database/sql"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Open inicia la conexión a la base de datos con las variables de entorno
+// DBUSER, DBPASS y DBADDR. La comparten el servidor y cmd/maintenance, para
+// que ambos se conecten exactamente igual.
+func Open() (*sql.DB, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = os.Getenv("DBUSER")
+	cfg.Passwd = os.Getenv("DBPASS")
+	cfg.Net = "tcp"
+	cfg.Addr = os.Getenv("DBADDR")
+	cfg.DBName = "railway"
+	cfg.ParseTime = true
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
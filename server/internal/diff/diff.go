@@ -0,0 +1,120 @@
+// Package diff compara dos versiones de un texto palabra por palabra.
+package diff
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tipos de operación
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// maxCells limita la tabla de LCS (len(a) * len(b) tokens). Con textos más
+// grandes Words se rinde y reporta el texto completo como reemplazado.
+const maxCells = 4_000_000
+
+// Op es un tramo del diff: texto que se conserva, se agrega o se quita.
+// Concatenar los tramos Equal y Delete da el texto viejo; Equal e Insert, el
+// nuevo.
+type Op struct {
+	Op    string `json:"op"`
+	Texto string `json:"texto"`
+}
+
+// Words regresa las operaciones que convierten a en b. Compara por palabras
+// (los espacios cuentan como tokens propios, así que se conservan tal cual) y
+// junta las operaciones consecutivas del mismo tipo.
+func Words(a, b string) []Op {
+	if a == b {
+		if a == "" {
+			return []Op{}
+		}
+		return []Op{{Op: Equal, Texto: a}}
+	}
+
+	x, y := split(a), split(b)
+	if len(x)*len(y) > maxCells {
+		return compact([]Op{{Op: Delete, Texto: a}, {Op: Insert, Texto: b}})
+	}
+
+	// lcs[i][j] es la subsecuencia común más larga de x[i:] y y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []Op
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			ops = append(ops, Op{Op: Equal, Texto: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, Op{Op: Delete, Texto: x[i]})
+			i++
+		default:
+			ops = append(ops, Op{Op: Insert, Texto: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		ops = append(ops, Op{Op: Delete, Texto: x[i]})
+	}
+	for ; j < len(y); j++ {
+		ops = append(ops, Op{Op: Insert, Texto: y[j]})
+	}
+
+	return compact(ops)
+}
+
+// split parte s en palabras y tramos de espacios
+func split(s string) []string {
+	var tokens []string
+	start := 0
+	for i, r := range s {
+		if i > start && unicode.IsSpace(r) != isSpaceAt(s, start) {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+func isSpaceAt(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return unicode.IsSpace(r)
+}
+
+// compact junta operaciones consecutivas del mismo tipo y quita las vacías
+func compact(ops []Op) []Op {
+	out := make([]Op, 0, len(ops))
+	for _, op := range ops {
+		if op.Texto == "" {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1].Op == op.Op {
+			out[n-1].Texto += op.Texto
+			continue
+		}
+		out = append(out, op)
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Op
+	}{
+		{name: "both empty", a: "", b: "", want: []Op{}},
+		{name: "equal", a: "hola mundo", b: "hola mundo", want: []Op{{Equal, "hola mundo"}}},
+		{name: "from empty", a: "", b: "hola", want: []Op{{Insert, "hola"}}},
+		{name: "to empty", a: "hola", b: "", want: []Op{{Delete, "hola"}}},
+		{
+			name: "word replaced",
+			a:    "terminé el módulo de pagos",
+			b:    "terminé el módulo de cobros",
+			want: []Op{{Equal, "terminé el módulo de "}, {Delete, "pagos"}, {Insert, "cobros"}},
+		},
+		{
+			name: "word inserted",
+			a:    "terminé el módulo",
+			b:    "terminé el nuevo módulo",
+			want: []Op{{Equal, "terminé el "}, {Insert, "nuevo "}, {Equal, "módulo"}},
+		},
+		{
+			name: "whitespace change",
+			a:    "a b",
+			b:    "a  b",
+			want: []Op{{Equal, "a"}, {Delete, " "}, {Insert, "  "}, {Equal, "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Words(tt.a, tt.b)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Words(%q, %q) = %v; want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// Las operaciones siempre deben reconstruir ambos textos
+func TestWordsReconstructs(t *testing.T) {
+	pairs := [][2]string{
+		{"uno dos tres", "tres dos uno"},
+		{"  espacios  al inicio", "espacios al final  "},
+		{"línea\nnueva", "línea nueva\n"},
+		{"ñandú pingüino", "pingüino ñandú ñandú"},
+		{strings.Repeat("palabra ", 2500), strings.Repeat("otra ", 2500)},
+	}
+
+	for _, p := range pairs {
+		var oldText, newText strings.Builder
+		for _, op := range Words(p[0], p[1]) {
+			if op.Texto == "" {
+				t.Errorf("Words(%.20q, %.20q) has an empty %s op", p[0], p[1], op.Op)
+			}
+			if op.Op != Insert {
+				oldText.WriteString(op.Texto)
+			}
+			if op.Op != Delete {
+				newText.WriteString(op.Texto)
+			}
+		}
+		if oldText.String() != p[0] || newText.String() != p[1] {
+			t.Errorf("Words(%.20q, %.20q) does not reconstruct its inputs", p[0], p[1])
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key es una llave de firma o verificación identificada por su kid. Private es
+// nil cuando solo se tiene la llave pública (llaves retiradas que se conservan
+// para verificar tokens que todavía no expiran).
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private any
+	Public  any
+}
+
+// Keyring agrupa la llave con la que se firman los tokens nuevos y todas las
+// llaves con las que se aceptan tokens, para poder rotar sin cerrar sesiones.
+type Keyring struct {
+	signing *Key
+	keys    map[string]*Key
+	// legacy es el secret HS256 de antes de las llaves asimétricas. Solo verifica
+	// tokens sin kid.
+	legacy *Key
+}
+
+// NewHMAC crea un Keyring que firma y verifica con un secret compartido (HS256).
+// Es el modo original de la aplicación.
+func NewHMAC(secret []byte) (*Keyring, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("jwtkeys: empty HMAC secret")
+	}
+	key := &Key{Method: jwt.SigningMethodHS256, Private: secret, Public: secret}
+	return &Keyring{signing: key, keys: map[string]*Key{}, legacy: key}, nil
+}
+
+// LoadDir carga todas las llaves *.pem de dir. El kid de cada llave es el nombre
+// del archivo sin extensión. Los archivos pueden tener una llave privada
+// (PKCS#8 o PKCS#1, RSA o Ed25519) o una llave pública (PKIX). La llave
+// signingKID debe ser privada y es la que firma los tokens nuevos.
+//
+// Si legacySecret no está vacío, también se aceptan tokens HS256 sin kid
+// firmados con él, para no invalidar las sesiones emitidas antes de la
+// migración. Conviene quitarlo una vez que esos tokens hayan expirado.
+func LoadDir(dir, signingKID string, legacySecret []byte) (*Keyring, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	kr := &Keyring{keys: make(map[string]*Key)}
+	for _, path := range paths {
+		key, err := loadKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: %s: %w", path, err)
+		}
+		kr.keys[key.ID] = key
+	}
+
+	signing, ok := kr.keys[signingKID]
+	if !ok {
+		return nil, fmt.Errorf("jwtkeys: signing key %q not found in %s", signingKID, dir)
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("jwtkeys: signing key %q has no private key", signingKID)
+	}
+	kr.signing = signing
+
+	if len(legacySecret) > 0 {
+		kr.legacy = &Key{Method: jwt.SigningMethodHS256, Public: legacySecret}
+	}
+
+	return kr, nil
+}
+
+func loadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	if pub, ok := key.Public.(*rsa.PublicKey); ok && pub.N.BitLen() < 2048 {
+		return nil, errors.New("RSA keys must be at least 2048 bits")
+	}
+
+	return key, nil
+}
+
+// Sign firma los claims con la llave activa, agregando su kid al header.
+func (kr *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(kr.signing.Method, claims)
+	if kr.signing.ID != "" {
+		token.Header["kid"] = kr.signing.ID
+	}
+	return token.SignedString(kr.signing.Private)
+}
+
+// Keyfunc elige la llave de verificación según el kid del token y comprueba que
+// el alg del header corresponda a esa llave, para evitar ataques de confusión
+// de algoritmo (por ejemplo, un token HS256 firmado con la llave pública RSA).
+func (kr *Keyring) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	var key *Key
+	if kid == "" {
+		key = kr.legacy
+	} else {
+		key = kr.keys[kid]
+	}
+	if key == nil {
+		return nil, fmt.Errorf("jwtkeys: unknown kid %q", kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("jwtkeys: unexpected alg %q for kid %q", token.Method.Alg(), kid)
+	}
+	return key.Public, nil
+}
+
+// Methods regresa los algoritmos que se aceptan al validar tokens.
+func (kr *Keyring) Methods() []string {
+	seen := make(map[string]bool)
+	if kr.legacy != nil {
+		seen[kr.legacy.Method.Alg()] = true
+	}
+	for _, key := range kr.keys {
+		seen[key.Method.Alg()] = true
+	}
+
+	methods := make([]string, 0, len(seen))
+	for alg := range seen {
+		methods = append(methods, alg)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// JWK es la representación pública de una llave según RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS regresa las llaves públicas de verificación en formato JWK Set. Los
+// secrets HMAC nunca se publican.
+func (kr *Keyring) JWKS() map[string][]JWK {
+	keys := []JWK{}
+
+	ids := make([]string, 0, len(kr.keys))
+	for id := range kr.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		key := kr.keys[id]
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	return map[string][]JWK{"keys": keys}
+}
@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Mailer es la abstracción que usa la aplicación para enviar correos. Permite
+// cambiar entre imprimir los correos en el log (desarrollo) y enviarlos por SMTP
+// sin tocar los handlers.
+type Mailer interface {
+	Send(recipient, subject, body string) error
+}
+
+// LogMailer no envía nada: escribe el correo completo en el logger. Útil en
+// desarrollo para copiar los links de verificación o de reset.
+type LogMailer struct {
+	Logger *slog.Logger
+}
+
+func (m *LogMailer) Send(recipient, subject, body string) error {
+	m.Logger.Info("email", "to", recipient, "subject", subject, "body", body)
+	return nil
+}
+
+// SMTPMailer envía correos de texto plano a un servidor SMTP, por ejemplo un
+// catcher local como MailHog o Mailpit. Si Username está vacío no se autentica.
+type SMTPMailer struct {
+	Addr     string
+	Sender   string
+	Username string
+	Password string
+}
+
+func (m *SMTPMailer) Send(recipient, subject, body string) error {
+	from, err := mail.ParseAddress(m.Sender)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		host := m.Addr
+		if i := strings.LastIndex(host, ":"); i != -1 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", recipient)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return smtp.SendMail(m.Addr, auth, from.Address, []string{recipient}, []byte(msg.String()))
+}
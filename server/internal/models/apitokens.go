@@ -0,0 +1,124 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+type APIToken struct {
+	ID        int        `json:"id_api_token"`
+	UserID    int        `json:"-"`
+	Nombre    string     `json:"nombre"`
+	Scopes    []string   `json:"scopes"`
+	ExpiraEn  *time.Time `json:"expira_en"`
+	UltimoUso *time.Time `json:"ultimo_uso"`
+	CreadoEn  time.Time  `json:"creado_en"`
+}
+
+type APITokensModel struct {
+	DB *sql.DB
+}
+
+func (m *APITokensModel) Insert(userID int, nombre, tokenHash string, scopes []string, expiraEn *time.Time) (int, error) {
+	stmt := `INSERT INTO api_token (id_usuario, nombre, token_hash, scopes, expira_en) VALUES(?, ?, ?, ?, ?)`
+	result, err := m.DB.Exec(stmt, userID, nombre, tokenHash, strings.Join(scopes, " "), expiraEn)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+func (m *APITokensModel) Get(id int) (APIToken, error) {
+	stmt := `SELECT id_api_token, id_usuario, nombre, scopes, expira_en, ultimo_uso, creado_en
+	FROM api_token WHERE id_api_token = ?`
+	return m.scanOne(m.DB.QueryRow(stmt, id))
+}
+
+func (m *APITokensModel) GetByHash(tokenHash string) (APIToken, error) {
+	stmt := `SELECT id_api_token, id_usuario, nombre, scopes, expira_en, ultimo_uso, creado_en
+	FROM api_token WHERE token_hash = ?`
+	return m.scanOne(m.DB.QueryRow(stmt, tokenHash))
+}
+
+func (m *APITokensModel) List(userID int) ([]APIToken, error) {
+	stmt := `SELECT id_api_token, id_usuario, nombre, scopes, expira_en, ultimo_uso, creado_en
+	FROM api_token WHERE id_usuario = ? ORDER BY creado_en DESC`
+	rows, err := m.DB.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []APIToken{}
+	for rows.Next() {
+		t, err := m.scanOne(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (m *APITokensModel) Touch(id int) error {
+	stmt := `UPDATE api_token SET ultimo_uso = ? WHERE id_api_token = ?`
+	_, err := m.DB.Exec(stmt, time.Now(), id)
+	return err
+}
+
+func (m *APITokensModel) Delete(id int) error {
+	stmt := `DELETE FROM api_token WHERE id_api_token = ?`
+
+	result, err := m.DB.Exec(stmt, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// DeleteAllForUser elimina todos los tokens de acceso personal del usuario
+func (m *APITokensModel) DeleteAllForUser(userID int) error {
+	stmt := `DELETE FROM api_token WHERE id_usuario = ?`
+	_, err := m.DB.Exec(stmt, userID)
+	return err
+}
+
+func (m *APITokensModel) scanOne(row interface{ Scan(...any) error }) (APIToken, error) {
+	var t APIToken
+	var scopes string
+	var expiraEn, ultimoUso sql.NullTime
+	err := row.Scan(&t.ID, &t.UserID, &t.Nombre, &scopes, &expiraEn, &ultimoUso, &t.CreadoEn)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return APIToken{}, ErrNoRecord
+		}
+		return APIToken{}, err
+	}
+	t.Scopes = strings.Fields(scopes)
+	if expiraEn.Valid {
+		t.ExpiraEn = &expiraEn.Time
+	}
+	if ultimoUso.Valid {
+		t.UltimoUso = &ultimoUso.Time
+	}
+	return t, nil
+}
@@ -2,6 +2,23 @@ package models
 
 import (
 	"errors"
+	"fmt"
 )
 
-var ErrNoRecord = errors.New("models: no matching record found")
\ No newline at end of file
+var ErrNoRecord = errors.New("models: no matching record found")
+
+var ErrInvalidToken = errors.New("models: invalid or expired token")
+
+var ErrTokenReused = errors.New("models: refresh token reused")
+
+var ErrEditConflict = errors.New("models: edit conflict")
+
+// DuplicateWeekError indica que el usuario ya tiene un registro cuya semana se
+// traslapa con la nueva.
+type DuplicateWeekError struct {
+	Existing Registro
+}
+
+func (e *DuplicateWeekError) Error() string {
+	return fmt.Sprintf("models: week overlaps registro %d", e.Existing.ID_Registro)
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+)
+
+type IdentitiesModel struct {
+	DB *sql.DB
+}
+
+func (m *IdentitiesModel) GetUserID(emisor, sujeto string) (int, error) {
+	stmt := `SELECT id_usuario FROM identidad_externa WHERE emisor = ? AND sujeto = ?`
+
+	var userID int
+	err := m.DB.QueryRow(stmt, emisor, sujeto).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNoRecord
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (m *IdentitiesModel) Insert(userID int, emisor, sujeto, email string) error {
+	stmt := `INSERT INTO identidad_externa (id_usuario, emisor, sujeto, email) VALUES(?, ?, ?, ?)`
+	_, err := m.DB.Exec(stmt, userID, emisor, sujeto, email)
+	return err
+}
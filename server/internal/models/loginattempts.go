@@ -0,0 +1,56 @@
+package models
+
+import (
+	"crud-web/internal/throttle"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// LoginAttemptsModel implementa throttle.Store sobre MySQL, de modo que el
+// conteo de fallos se comparta entre varias instancias del servidor.
+type LoginAttemptsModel struct {
+	DB *sql.DB
+}
+
+func (m *LoginAttemptsModel) Get(key string) (throttle.Record, error) {
+	stmt := `SELECT fallos, ultimo_fallo, bloqueado_hasta FROM intento_login WHERE llave = ?`
+
+	var rec throttle.Record
+	var lockedUntil sql.NullTime
+	err := m.DB.QueryRow(stmt, key).Scan(&rec.Failures, &rec.LastFailure, &lockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return throttle.Record{}, nil
+		}
+		return throttle.Record{}, err
+	}
+	rec.LockedUntil = lockedUntil.Time
+	return rec, nil
+}
+
+func (m *LoginAttemptsModel) Increment(key string, now time.Time, window time.Duration) (throttle.Record, error) {
+	// MySQL evalúa las asignaciones en orden, así que fallos se calcula con el
+	// ultimo_fallo anterior.
+	stmt := `INSERT INTO intento_login (llave, fallos, ultimo_fallo) VALUES(?, 1, ?)
+	ON DUPLICATE KEY UPDATE
+		fallos = IF(ultimo_fallo < ?, 1, fallos + 1),
+		ultimo_fallo = ?`
+	_, err := m.DB.Exec(stmt, key, now, now.Add(-window), now)
+	if err != nil {
+		return throttle.Record{}, err
+	}
+	return m.Get(key)
+}
+
+func (m *LoginAttemptsModel) Lock(key string, until time.Time) error {
+	stmt := `UPDATE intento_login SET bloqueado_hasta = ? WHERE llave = ?`
+	_, err := m.DB.Exec(stmt, until, key)
+	return err
+}
+
+func (m *LoginAttemptsModel) Reset(key string) error {
+	stmt := `DELETE FROM intento_login WHERE llave = ?`
+	_, err := m.DB.Exec(stmt, key)
+	return err
+}
@@ -4,21 +4,28 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 )
 
 type Logro struct {
 	ID_Logro     int    `json:"id_logro"`
+	ID_Registro  int    `json:"-"`
+	Posicion     int    `json:"posicion"`
 	Titulo       string `json:"titulo"`
 	Descripcion  string `json:"descripcion"`
+	Version      int    `json:"-"`
 }
 
 type LogrosModel struct {
-    DB *sql.DB
+    DB DBTX
 }
 
-func (m *LogrosModel) Insert(titulo string, descripcion string) (int, error) {
-	stmt := `INSERT INTO logro (titulo, descripcion) VALUES(?, ?)`
-    result, err := m.DB.Exec(stmt, titulo, descripcion)
+// Insert agrega el logro al final de la lista del registro id_registro y guarda
+// su contenido inicial como revisión 1, hecha por autor.
+func (m *LogrosModel) Insert(autor int, id_registro int, titulo string, descripcion string) (int, error) {
+	stmt := `INSERT INTO logro (id_registro, posicion, titulo, descripcion)
+	SELECT ?, COALESCE(MAX(posicion) + 1, 0), ?, ? FROM logro WHERE id_registro = ?`
+    result, err := m.DB.Exec(stmt, id_registro, titulo, descripcion, id_registro)
     if err != nil {
         return 0, err
     }
@@ -26,15 +33,20 @@ func (m *LogrosModel) Insert(titulo string, descripcion string) (int, error) {
     if err != nil {
         return 0, err
     }
+
+    err = m.insertRevision(int(id), 1, autor, titulo, descripcion)
+    if err != nil {
+        return 0, err
+    }
     return int(id), nil
 }
 
 func (m *LogrosModel) Get(id int) (Logro, error) {
-    stmt := `SELECT id_logro, titulo, descripcion FROM logro WHERE id_logro = ?`
+    stmt := `SELECT id_logro, id_registro, posicion, titulo, descripcion, version FROM logro WHERE id_logro = ?`
     row := m.DB.QueryRow(stmt, id)
 
     var l Logro
-    err := row.Scan(&l.ID_Logro, &l.Titulo, &l.Descripcion)
+    err := row.Scan(&l.ID_Logro, &l.ID_Registro, &l.Posicion, &l.Titulo, &l.Descripcion, &l.Version)
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
             return Logro{}, ErrNoRecord
@@ -45,27 +57,44 @@ func (m *LogrosModel) Get(id int) (Logro, error) {
     return l, nil
 }
 
-func (m *LogrosModel) Update(id int, titulo string, descripcion string) error {
-    stmt := `UPDATE logro SET titulo = ?, descripcion = ? WHERE id_logro = ?`
-    result, err := m.DB.Exec(stmt, titulo, descripcion, id)
+// Update guarda los cambios solo si el logro sigue en la versión version y la
+// incrementa. Si otro request lo modificó antes regresa ErrEditConflict. El
+// contenido nuevo queda en el historial como una revisión de autor; conviene
+// llamarlo dentro de una transacción para que ambas escrituras vayan juntas.
+func (m *LogrosModel) Update(id int, version int, autor int, titulo string, descripcion string) error {
+    stmt := `UPDATE logro SET titulo = ?, descripcion = ?, version = version + 1
+    WHERE id_logro = ? AND version = ?`
+    result, err := m.DB.Exec(stmt, titulo, descripcion, id, version)
     if err != nil {
         fmt.Println("error in the statement")
         return err
     }
     
-    _, err = result.RowsAffected()
+    rowsAffected, err := result.RowsAffected()
     if err != nil {
         fmt.Println("error in the rows affected")
         return err
     }
+
+    if rowsAffected == 0 {
+        return ErrEditConflict
+    }
     
-    return nil
+    return m.insertRevision(id, version+1, autor, titulo, descripcion)
+}
+
+func (m *LogrosModel) insertRevision(id int, revision int, autor int, titulo string, descripcion string) error {
+    stmt := `INSERT INTO logro_revision (id_logro, revision, titulo, descripcion, id_usuario) VALUES(?, ?, ?, ?, ?)`
+    _, err := m.DB.Exec(stmt, id, revision, titulo, descripcion, autor)
+    return err
 }
 
-func (m *LogrosModel) Delete(id int) error {
-    stmt := `DELETE FROM logro WHERE id_logro = ?`
+// Delete elimina el logro solo si sigue en la versión version; si no, regresa
+// ErrEditConflict.
+func (m *LogrosModel) Delete(id int, version int) error {
+    stmt := `DELETE FROM logro WHERE id_logro = ? AND version = ?`
     
-    result, err := m.DB.Exec(stmt, id)
+    result, err := m.DB.Exec(stmt, id, version)
     if err != nil {
         return err
     }
@@ -76,8 +105,56 @@ func (m *LogrosModel) Delete(id int) error {
     }
     
     if rowsAffected == 0 {
-        return ErrNoRecord
+        return ErrEditConflict
     }
     
     return nil
 }
+
+// Reorder deja los logros del registro id_registro en el orden de ids, que
+// debe contenerlos a todos.
+func (m *LogrosModel) Reorder(id_registro int, ids []int) error {
+    stmt := `UPDATE logro SET posicion = ? WHERE id_logro = ? AND id_registro = ?`
+    for posicion, id := range ids {
+        _, err := m.DB.Exec(stmt, posicion, id, id_registro)
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// logrosFor regresa, en orden, los logros de cada uno de los registros ids
+func logrosFor(db DBTX, ids []int) (map[int][]Logro, error) {
+    logros := make(map[int][]Logro, len(ids))
+    if len(ids) == 0 {
+        return logros, nil
+    }
+
+    args := make([]any, len(ids))
+    for i, id := range ids {
+        args[i] = id
+    }
+    placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+    stmt := `SELECT id_logro, id_registro, posicion, titulo, descripcion, version FROM logro
+    WHERE id_registro IN (` + placeholders + `) ORDER BY id_registro, posicion, id_logro`
+    rows, err := db.Query(stmt, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var l Logro
+        err = rows.Scan(&l.ID_Logro, &l.ID_Registro, &l.Posicion, &l.Titulo, &l.Descripcion, &l.Version)
+        if err != nil {
+            return nil, err
+        }
+        logros[l.ID_Registro] = append(logros[l.ID_Registro], l)
+    }
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+    return logros, nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+)
+
+// Los huecos que limpia este archivo vienen de cuando registro y logro se
+// creaban y borraban en sentencias separadas, sin transacción. Desde que cada
+// logro apunta a su registro con una llave foránea ya no puede quedar un logro
+// sin registro, pero sí una semana sin logros.
+
+// Dangling regresa los registros que no tienen ningún logro. Solo considera
+// los que no se han modificado desde olderThan, para no tocar un registro que
+// se está creando en este momento.
+func (m *RegistrosModel) Dangling(olderThan time.Time) ([]int, error) {
+	stmt := `SELECT r.id_registro FROM registro r
+	LEFT JOIN logro l ON l.id_registro = r.id_registro
+	WHERE l.id_logro IS NULL AND r.actualizado_en < ?
+	ORDER BY r.id_registro`
+	return queryIDs(m.DB, stmt, olderThan)
+}
+
+func (m *RegistrosModel) DeleteDangling(olderThan time.Time) (int, error) {
+	stmt := `DELETE r FROM registro r
+	LEFT JOIN logro l ON l.id_registro = r.id_registro
+	WHERE l.id_logro IS NULL AND r.actualizado_en < ?`
+	return execCount(m.DB, stmt, olderThan)
+}
+
+func queryIDs(db DBTX, stmt string, args ...any) ([]int, error) {
+	rows, err := db.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func execCount(db DBTX, stmt string, args ...any) (int, error) {
+	result, err := db.Exec(stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type RecoveryCodesModel struct {
+	DB *sql.DB
+}
+
+// Replace borra los códigos de recuperación del usuario y guarda los nuevos
+// hashes en una sola transacción.
+func (m *RecoveryCodesModel) Replace(userID int, hashes []string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM codigo_recuperacion WHERE id_usuario = ?`, userID)
+	if err != nil {
+		return err
+	}
+
+	stmt := `INSERT INTO codigo_recuperacion (id_usuario, codigo_hash) VALUES(?, ?)`
+	for _, hash := range hashes {
+		_, err = tx.Exec(stmt, userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *RecoveryCodesModel) Consume(userID int, hash string) error {
+	stmt := `UPDATE codigo_recuperacion SET usado_en = ?
+	WHERE id_usuario = ? AND codigo_hash = ? AND usado_en IS NULL`
+	result, err := m.DB.Exec(stmt, time.Now(), userID, hash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+func (m *RecoveryCodesModel) DeleteAll(userID int) error {
+	_, err := m.DB.Exec(`DELETE FROM codigo_recuperacion WHERE id_usuario = ?`, userID)
+	return err
+}
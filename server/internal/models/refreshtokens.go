@@ -0,0 +1,119 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type RefreshToken struct {
+	ID         int
+	UserID     int
+	Familia    string
+	ExpiraEn   time.Time
+	UsadoEn    sql.NullTime
+	RevocadoEn sql.NullTime
+}
+
+type RefreshTokensModel struct {
+	DB *sql.DB
+}
+
+func (m *RefreshTokensModel) Insert(userID int, familia, tokenHash string, expiraEn time.Time) error {
+	stmt := `INSERT INTO refresh_token (id_usuario, familia, token_hash, expira_en) VALUES(?, ?, ?, ?)`
+	_, err := m.DB.Exec(stmt, userID, familia, tokenHash, expiraEn)
+	return err
+}
+
+func (m *RefreshTokensModel) GetByHash(tokenHash string) (RefreshToken, error) {
+	stmt := `SELECT id_refresh_token, id_usuario, familia, expira_en, usado_en, revocado_en
+	FROM refresh_token WHERE token_hash = ?`
+	row := m.DB.QueryRow(stmt, tokenHash)
+
+	var t RefreshToken
+	err := row.Scan(&t.ID, &t.UserID, &t.Familia, &t.ExpiraEn, &t.UsadoEn, &t.RevocadoEn)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RefreshToken{}, ErrNoRecord
+		}
+		return RefreshToken{}, err
+	}
+	return t, nil
+}
+
+// Rotate canjea el refresh token identificado por oldHash por uno nuevo de la
+// misma familia. Si el token ya había sido usado o revocado se trata como una
+// reutilización: se revoca toda la familia y se regresa ErrTokenReused.
+func (m *RefreshTokensModel) Rotate(oldHash, newHash string, expiraEn time.Time) (RefreshToken, error) {
+	t, err := m.GetByHash(oldHash)
+	if err != nil {
+		if errors.Is(err, ErrNoRecord) {
+			return RefreshToken{}, ErrInvalidToken
+		}
+		return RefreshToken{}, err
+	}
+
+	if t.UsadoEn.Valid || t.RevocadoEn.Valid {
+		err = m.RevokeFamily(t.Familia)
+		if err != nil {
+			return RefreshToken{}, err
+		}
+		return t, ErrTokenReused
+	}
+
+	if time.Now().After(t.ExpiraEn) {
+		return RefreshToken{}, ErrInvalidToken
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	defer tx.Rollback()
+
+	stmt := `UPDATE refresh_token SET usado_en = ?
+	WHERE id_refresh_token = ? AND usado_en IS NULL AND revocado_en IS NULL`
+	result, err := tx.Exec(stmt, time.Now(), t.ID)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	// Otro request canjeó el mismo token entre la lectura y el UPDATE.
+	if rowsAffected == 0 {
+		tx.Rollback()
+		err = m.RevokeFamily(t.Familia)
+		if err != nil {
+			return RefreshToken{}, err
+		}
+		return t, ErrTokenReused
+	}
+
+	stmt = `INSERT INTO refresh_token (id_usuario, familia, token_hash, expira_en) VALUES(?, ?, ?, ?)`
+	_, err = tx.Exec(stmt, t.UserID, t.Familia, newHash, expiraEn)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	return t, nil
+}
+
+func (m *RefreshTokensModel) RevokeFamily(familia string) error {
+	stmt := `UPDATE refresh_token SET revocado_en = ? WHERE familia = ? AND revocado_en IS NULL`
+	_, err := m.DB.Exec(stmt, time.Now(), familia)
+	return err
+}
+
+func (m *RefreshTokensModel) RevokeAllForUser(userID int) error {
+	stmt := `UPDATE refresh_token SET revocado_en = ? WHERE id_usuario = ? AND revocado_en IS NULL`
+	_, err := m.DB.Exec(stmt, time.Now(), userID)
+	return err
+}
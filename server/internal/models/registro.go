@@ -3,60 +3,262 @@ package models
 import (
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
+
+	"github.com/go-sql-driver/mysql"
 )
 
 type Registro struct {
-	ID_Registro    int       `json:"id_registro"`
-	ID_Usuario     int       `json:"id_usuario"`
-	ID_Logro       int       `json:"id_logro"`
-	InicioSemana   time.Time `json:"inicio_semana"`
-	FinSemana      time.Time `json:"fin_semana"`
+	ID_Registro    int        `json:"id_registro"`
+	ID_Usuario     int        `json:"id_usuario"`
+	InicioSemana   time.Time  `json:"inicio_semana"`
+	FinSemana      time.Time  `json:"fin_semana"`
+	Version        int        `json:"-"`
+	EliminadoEn    *time.Time `json:"eliminado_en,omitempty"`
+}
+
+// RegistroConLogros es un registro junto con sus logros, en orden
+type RegistroConLogros struct {
+	Registro      Registro
+	Logros        []Logro
+	ActualizadoEn time.Time
+}
+
+// Principal regresa el primer logro del registro, el que ven los clientes que
+// solo conocen un logro por registro.
+func (r RegistroConLogros) Principal() Logro {
+	if len(r.Logros) == 0 {
+		return Logro{}
+	}
+	return r.Logros[0]
+}
+
+// Logro busca uno de los logros del registro por su id
+func (r RegistroConLogros) Logro(id int) (Logro, bool) {
+	for _, l := range r.Logros {
+		if l.ID_Logro == id {
+			return l, true
+		}
+	}
+	return Logro{}, false
+}
+
+const (
+	SortInicioSemanaDesc = "-inicio_semana"
+	SortInicioSemanaAsc  = "inicio_semana"
+	SortTitulo           = "titulo"
+)
+
+var RegistroSorts = []string{SortInicioSemanaDesc, SortInicioSemanaAsc, SortTitulo}
+
+// Qué hacer al crear un registro cuya semana se traslapa con otro del usuario
+const (
+	DuplicateReject = "reject"
+	DuplicateAllow  = "allow"
+	DuplicateMerge  = "merge"
+)
+
+var DuplicatePolicies = []string{DuplicateReject, DuplicateAllow, DuplicateMerge}
+
+// RegistroFilter limita y ordena los registros de Page y Count. Desde y Hasta
+// dejan solo las semanas que empiezan en o después de Desde y terminan en o
+// antes de Hasta. Texto busca en el título y la descripción de sus logros.
+type RegistroFilter struct {
+	Desde *time.Time
+	Hasta *time.Time
+	Texto string
+	Orden string
 }
 
+// RegistroCursor identifica la posición de un registro en el orden de Page.
+// Solo se usa el campo que corresponde al orden; Titulo es el del logro
+// principal.
+type RegistroCursor struct {
+	InicioSemana time.Time
+	Titulo       string
+	ID_Registro  int
+}
 
 type RegistrosModel struct {
-    DB *sql.DB
+    DB DBTX
 }
 
-func (m *RegistrosModel) Insert(id_usuario int, id_logro int, inicio_semana time.Time, fin_semana time.Time) (int, error) {
-	stmt := `INSERT INTO registro (id_usuario, id_logro, inicio_semana, fin_semana) VALUES(?, ?, ?, ?)`
-    result, err := m.DB.Exec(stmt, id_usuario, id_logro, inicio_semana, fin_semana)
+// Insert crea el registro aplicando policy cuando el usuario ya tiene otro
+// registro cuya semana se traslapa con [inicio_semana, fin_semana]:
+//   - DuplicateReject regresa *DuplicateWeekError con el registro existente.
+//   - DuplicateAllow lo crea de todos modos.
+//   - DuplicateMerge no crea nada y regresa el registro existente con merged
+//     en true, para que el llamador le agregue el logro.
+//
+// Con reject y merge además se llena semana_unica, y si el índice
+// uq_registro_semana rechaza el insert se trata igual que un traslape. Dentro
+// de una transacción, dos inserts simultáneos de la misma semana pueden pasar
+// los dos la revisión de Overlapping y chocar en el INSERT; InnoDB aborta uno
+// con deadlock y UnitOfWork.WithTx lo repite, y en el segundo intento
+// Overlapping ya ve el registro del otro.
+func (m *RegistrosModel) Insert(id_usuario int, inicio_semana time.Time, fin_semana time.Time, policy string) (registro Registro, merged bool, err error) {
+    if policy != DuplicateAllow {
+        existing, err := m.Overlapping(id_usuario, inicio_semana, fin_semana, 0)
+        if err == nil {
+            return onDuplicate(existing, policy)
+        } else if !errors.Is(err, ErrNoRecord) {
+            return Registro{}, false, err
+        }
+    }
+
+	var semanaUnica any
+	if policy != DuplicateAllow {
+		semanaUnica = inicio_semana
+	}
+
+	stmt := `INSERT INTO registro (id_usuario, inicio_semana, fin_semana, semana_unica) VALUES(?, ?, ?, ?)`
+    result, err := m.DB.Exec(stmt, id_usuario, inicio_semana, fin_semana, semanaUnica)
     if err != nil {
-        return 0, err
+        var duplicate *DuplicateWeekError
+        if errors.As(m.duplicateWeek(err, id_usuario, inicio_semana, fin_semana, 0), &duplicate) {
+            return onDuplicate(duplicate.Existing, policy)
+        }
+        return Registro{}, false, err
     }
     id, err := result.LastInsertId()
     if err != nil {
-        return 0, err
+        return Registro{}, false, err
     }
-    return int(id), nil
+    s := Registro{ID_Registro: int(id), ID_Usuario: id_usuario, InicioSemana: inicio_semana, FinSemana: fin_semana, Version: 1}
+    return s, false, nil
 }
 
-func (m *RegistrosModel) Get(id int) (Registro, error) {
-    stmt := `SELECT id_registro, id_usuario, id_logro, inicio_semana, fin_semana FROM registro
-    WHERE id_registro = ?`
-    row := m.DB.QueryRow(stmt, id)
+func onDuplicate(existing Registro, policy string) (Registro, bool, error) {
+	if policy == DuplicateMerge {
+		return existing, true, nil
+	}
+	return Registro{}, false, &DuplicateWeekError{Existing: existing}
+}
+
+// Overlapping regresa el registro más antiguo del usuario, fuera de la
+// papelera y distinto de exclude, cuya semana se traslapa con [inicio, fin].
+// Si no hay ninguno regresa ErrNoRecord.
+//
+// Dentro de una transacción usa FOR UPDATE: el registro encontrado queda
+// bloqueado hasta el commit. Si no encuentra nada, InnoDB solo toma gap locks
+// sobre el rango del índice, y esos no se excluyen entre transacciones, así que
+// no impiden que otra transacción haga la misma revisión al mismo tiempo.
+func (m *RegistrosModel) Overlapping(id_usuario int, inicio, fin time.Time, exclude int) (Registro, error) {
+    stmt := `SELECT id_registro, id_usuario, inicio_semana, fin_semana, version FROM registro
+    WHERE id_usuario = ? AND inicio_semana <= ? AND fin_semana >= ? AND eliminado_en IS NULL AND id_registro <> ?
+    ORDER BY inicio_semana, id_registro LIMIT 1 FOR UPDATE`
+    row := m.DB.QueryRow(stmt, id_usuario, fin, inicio, exclude)
 
     var s Registro
-    err := row.Scan(&s.ID_Registro, &s.ID_Usuario, &s.ID_Logro, &s.InicioSemana, &s.FinSemana)
+    err := row.Scan(&s.ID_Registro, &s.ID_Usuario, &s.InicioSemana, &s.FinSemana, &s.Version)
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
             return Registro{}, ErrNoRecord
-        } else {
-            return Registro{}, err
         }
+        return Registro{}, err
     }
     return s, nil
 }
 
-func (m *RegistrosModel) Update(id int, id_usuario int, id_logro int, inicio_semana time.Time, fin_semana time.Time) error {
+// duplicateWeek convierte el error de llave duplicada de uq_registro_semana en
+// *DuplicateWeekError con el registro que ocupa la semana. Cualquier otro error
+// lo regresa sin cambios.
+func (m *RegistrosModel) duplicateWeek(err error, id_usuario int, inicio, fin time.Time, exclude int) error {
+	var mySQLError *mysql.MySQLError
+	if !errors.As(err, &mySQLError) || mySQLError.Number != 1062 || !strings.Contains(mySQLError.Message, "uq_registro_semana") {
+		return err
+	}
+
+	existing, lookupErr := m.Overlapping(id_usuario, inicio, fin, exclude)
+	if lookupErr != nil {
+		return err
+	}
+	return &DuplicateWeekError{Existing: existing}
+}
+
+// GetWithLogros regresa el registro junto con sus logros. Los registros en la
+// papelera no se encuentran.
+func (m *RegistrosModel) GetWithLogros(id int) (RegistroConLogros, error) {
+    return m.getWithLogros(id, false)
+}
+
+// GetTrashed es como GetWithLogros pero solo encuentra registros en la papelera
+func (m *RegistrosModel) GetTrashed(id int) (RegistroConLogros, error) {
+    return m.getWithLogros(id, true)
+}
+
+func (m *RegistrosModel) getWithLogros(id int, trashed bool) (RegistroConLogros, error) {
+    state := `r.eliminado_en IS NULL`
+    if trashed {
+        state = `r.eliminado_en IS NOT NULL`
+    }
+    stmt := `SELECT ` + registroColumns + ` FROM registro r
+    WHERE r.id_registro = ? AND ` + state
+
+    registros, err := m.query(stmt, id)
+    if err != nil {
+        return RegistroConLogros{}, err
+    }
+    if len(registros) == 0 {
+        return RegistroConLogros{}, ErrNoRecord
+    }
+    return registros[0], nil
+}
+
+// registroColumns son las columnas que escanea query. ActualizadoEn es la del
+// registro, que sube de versión con cualquier cambio a sus logros.
+const registroColumns = `r.id_registro, r.id_usuario, r.inicio_semana, r.fin_semana, r.version, r.eliminado_en, r.actualizado_en`
+
+// query ejecuta un SELECT de registroColumns y completa cada registro con sus
+// logros en una segunda consulta.
+func (m *RegistrosModel) query(stmt string, args ...any) ([]RegistroConLogros, error) {
+    rows, err := m.DB.Query(stmt, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var registros []RegistroConLogros
+    var ids []int
+
+    for rows.Next() {
+        var s RegistroConLogros
+        err = rows.Scan(&s.Registro.ID_Registro, &s.Registro.ID_Usuario, &s.Registro.InicioSemana, &s.Registro.FinSemana, &s.Registro.Version, &s.Registro.EliminadoEn, &s.ActualizadoEn)
+        if err != nil {
+            return nil, err
+        }
+        registros = append(registros, s)
+        ids = append(ids, s.Registro.ID_Registro)
+    }
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+    rows.Close()
+
+    logros, err := logrosFor(m.DB, ids)
+    if err != nil {
+        return nil, err
+    }
+    for i := range registros {
+        registros[i].Logros = logros[registros[i].Registro.ID_Registro]
+    }
+    return registros, nil
+}
+
+// Update guarda los cambios solo si el registro sigue en la versión version y la
+// incrementa. Si otro request lo modificó antes regresa ErrEditConflict. Si el
+// registro tiene semana_unica, esta sigue a inicio_semana; si eso choca con
+// otro registro del usuario regresa *DuplicateWeekError.
+func (m *RegistrosModel) Update(id int, version int, id_usuario int, inicio_semana time.Time, fin_semana time.Time) error {
     stmt := `UPDATE registro 
-    SET id_usuario = ?, id_logro = ?, inicio_semana = ?, fin_semana = ?
-    WHERE id_registro = ?`
+    SET id_usuario = ?, inicio_semana = ?, fin_semana = ?, semana_unica = IF(semana_unica IS NULL, NULL, inicio_semana),
+    version = version + 1
+    WHERE id_registro = ? AND version = ?`
     
-    result, err := m.DB.Exec(stmt, id_usuario, id_logro, inicio_semana, fin_semana, id)
+    result, err := m.DB.Exec(stmt, id_usuario, inicio_semana, fin_semana, id, version)
     if err != nil {
-        return err
+        return m.duplicateWeek(err, id_usuario, inicio_semana, fin_semana, id)
     }
     
     rowsAffected, err := result.RowsAffected()
@@ -65,16 +267,18 @@ func (m *RegistrosModel) Update(id int, id_usuario int, id_logro int, inicio_sem
     }
     
     if rowsAffected == 0 {
-        return ErrNoRecord
+        return ErrEditConflict
     }
     
     return nil
 }
 
-func (m *RegistrosModel) Delete(id int) error {
-    stmt := `DELETE FROM registro WHERE id_registro = ?`
+// Delete elimina el registro solo si sigue en la versión version; si no,
+// regresa ErrEditConflict.
+func (m *RegistrosModel) Delete(id int, version int) error {
+    stmt := `DELETE FROM registro WHERE id_registro = ? AND version = ?`
     
-    result, err := m.DB.Exec(stmt, id)
+    result, err := m.DB.Exec(stmt, id, version)
     if err != nil {
         return err
     }
@@ -85,33 +289,170 @@ func (m *RegistrosModel) Delete(id int) error {
     }
     
     if rowsAffected == 0 {
-        return ErrNoRecord
+        return ErrEditConflict
     }
     
     return nil
 }
 
-func (m *RegistrosModel) Latest(id int) ([]Registro, error) {
-    stmt := `SELECT id_registro, id_usuario, id_logro, inicio_semana, fin_semana FROM registro
-    WHERE id_usuario = ? ORDER BY inicio_semana DESC LIMIT 10`
-    rows, err := m.DB.Query(stmt, id)
+// Touch sube la versión del registro sin cambiar sus datos, para que agregar,
+// editar, eliminar o reordenar sus logros invalide los ETags anteriores. Si el
+// registro ya no está en la versión version regresa ErrEditConflict.
+func (m *RegistrosModel) Touch(id int, version int) error {
+    stmt := `UPDATE registro SET version = version + 1
+    WHERE id_registro = ? AND version = ? AND eliminado_en IS NULL`
+    return m.execVersioned(stmt, id, version)
+}
+
+// Trash manda el registro a la papelera solo si sigue en la versión version;
+// si no, regresa ErrEditConflict. Libera su semana_unica para que la semana se
+// pueda volver a usar.
+func (m *RegistrosModel) Trash(id int, version int) error {
+    stmt := `UPDATE registro SET eliminado_en = ?, semana_unica = NULL, version = version + 1
+    WHERE id_registro = ? AND version = ? AND eliminado_en IS NULL`
+    return m.execVersioned(stmt, time.Now(), id, version)
+}
+
+// Restore saca el registro de la papelera solo si sigue en la versión de
+// registro; si no, regresa ErrEditConflict. Con policy reject o merge, si otro
+// registro del usuario ya ocupa su semana regresa *DuplicateWeekError y lo deja
+// en la papelera: restaurar no fusiona.
+func (m *RegistrosModel) Restore(registro Registro, policy string) error {
+    if policy != DuplicateAllow {
+        existing, err := m.Overlapping(registro.ID_Usuario, registro.InicioSemana, registro.FinSemana, registro.ID_Registro)
+        if err == nil {
+            return &DuplicateWeekError{Existing: existing}
+        } else if !errors.Is(err, ErrNoRecord) {
+            return err
+        }
+    }
+
+    stmt := `UPDATE registro SET eliminado_en = NULL, semana_unica = IF(?, inicio_semana, NULL), version = version + 1
+    WHERE id_registro = ? AND version = ? AND eliminado_en IS NOT NULL`
+    err := m.execVersioned(stmt, policy != DuplicateAllow, registro.ID_Registro, registro.Version)
     if err != nil {
-        return nil, err
+        return m.duplicateWeek(err, registro.ID_Usuario, registro.InicioSemana, registro.FinSemana, registro.ID_Registro)
     }
-    defer rows.Close()
+    return nil
+}
 
-    var registros []Registro
+func (m *RegistrosModel) execVersioned(stmt string, args ...any) error {
+    result, err := m.DB.Exec(stmt, args...)
+    if err != nil {
+        return err
+    }
 
-    for rows.Next() {
-        var s Registro
-        err = rows.Scan(&s.ID_Registro, &s.ID_Usuario, &s.ID_Logro, &s.InicioSemana, &s.FinSemana)
-        if err != nil {
-            return nil, err
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        return ErrEditConflict
+    }
+    return nil
+}
+
+// Trashed regresa los registros del usuario que están en la papelera, del
+// eliminado más recientemente al más antiguo.
+func (m *RegistrosModel) Trashed(id int) ([]RegistroConLogros, error) {
+    stmt := `SELECT ` + registroColumns + ` FROM registro r
+    WHERE r.id_usuario = ? AND r.eliminado_en IS NOT NULL
+    ORDER BY r.eliminado_en DESC, r.id_registro DESC`
+    return m.query(stmt, id)
+}
+
+// PurgeTrashed elimina definitivamente los registros que entraron a la
+// papelera antes de before; sus logros se van con ellos por ON DELETE CASCADE.
+// Regresa cuántos registros eliminó.
+func (m *RegistrosModel) PurgeTrashed(before time.Time) (int, error) {
+    stmt := `DELETE FROM registro WHERE eliminado_en IS NOT NULL AND eliminado_en < ?`
+    return execCount(m.DB, stmt, before)
+}
+
+// tituloPrincipal es el título del primer logro del registro r. Un registro
+// sin logros da '' en lugar de NULL, porque NULL no cumple ninguna comparación
+// del cursor y esos registros se saltarían o repetirían entre páginas.
+const tituloPrincipal = `COALESCE((SELECT lp.titulo FROM logro lp WHERE lp.id_registro = r.id_registro ORDER BY lp.posicion, lp.id_logro LIMIT 1), '')`
+
+// registroOrders define, para cada orden permitido, el ORDER BY y la condición
+// para continuar después del cursor. Nunca se interpola texto del usuario.
+var registroOrders = map[string]struct {
+    orderBy string
+    after   string
+}{
+    SortInicioSemanaDesc: {
+        orderBy: `r.inicio_semana DESC, r.id_registro DESC`,
+        after:   `(r.inicio_semana < ? OR (r.inicio_semana = ? AND r.id_registro < ?))`,
+    },
+    SortInicioSemanaAsc: {
+        orderBy: `r.inicio_semana ASC, r.id_registro ASC`,
+        after:   `(r.inicio_semana > ? OR (r.inicio_semana = ? AND r.id_registro > ?))`,
+    },
+    SortTitulo: {
+        orderBy: tituloPrincipal + ` ASC, r.id_registro ASC`,
+        after:   `(` + tituloPrincipal + ` > ? OR (` + tituloPrincipal + ` = ? AND r.id_registro > ?))`,
+    },
+}
+
+// where arma el WHERE compartido por Page y Count
+func (f RegistroFilter) where(id int) (string, []any) {
+    clause := `WHERE r.id_usuario = ? AND r.eliminado_en IS NULL`
+    args := []any{id}
+
+    if f.Desde != nil {
+        clause += ` AND r.inicio_semana >= ?`
+        args = append(args, *f.Desde)
+    }
+    if f.Hasta != nil {
+        clause += ` AND r.fin_semana <= ?`
+        args = append(args, *f.Hasta)
+    }
+    if f.Texto != "" {
+        pattern := "%" + likeEscaper.Replace(f.Texto) + "%"
+        clause += ` AND EXISTS (SELECT 1 FROM logro l WHERE l.id_registro = r.id_registro AND (l.titulo LIKE ? OR l.descripcion LIKE ?))`
+        args = append(args, pattern, pattern)
+    }
+    return clause, args
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Page regresa hasta limit registros del usuario que cumplen el filtro, cada
+// uno con sus logros. El orden es el de filter.Orden (por defecto del más
+// reciente al más antiguo). Si after no es nil, empieza justo
+// después de ese registro; desempatar por id_registro hace que el orden sea
+// estable aunque varios registros compartan el valor.
+func (m *RegistrosModel) Page(id int, filter RegistroFilter, after *RegistroCursor, limit int) ([]RegistroConLogros, error) {
+    order, ok := registroOrders[filter.Orden]
+    if !ok {
+        order = registroOrders[SortInicioSemanaDesc]
+    }
+
+    where, args := filter.where(id)
+    stmt := `SELECT ` + registroColumns + ` FROM registro r ` + where
+    if after != nil {
+        var value any = after.InicioSemana
+        if filter.Orden == SortTitulo {
+            value = after.Titulo
         }
-        registros = append(registros, s)
+        stmt += ` AND ` + order.after
+        args = append(args, value, value, after.ID_Registro)
     }
-    if err = rows.Err(); err != nil {
-        return nil, err
+    stmt += ` ORDER BY ` + order.orderBy + ` LIMIT ?`
+    args = append(args, limit)
+
+    return m.query(stmt, args...)
+}
+
+func (m *RegistrosModel) Count(id int, filter RegistroFilter) (int, error) {
+    where, args := filter.where(id)
+    stmt := `SELECT COUNT(*) FROM registro r ` + where
+
+    var total int
+    err := m.DB.QueryRow(stmt, args...).Scan(&total)
+    if err != nil {
+        return 0, err
     }
-    return registros, nil
-}
\ No newline at end of file
+    return total, nil
+}
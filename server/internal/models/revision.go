@@ -0,0 +1,86 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// LogroRevision es el contenido de un logro en una de sus versiones. Autor es
+// nil si la cuenta que hizo el cambio ya no existe.
+type LogroRevision struct {
+	Revision    int       `json:"revision"`
+	Titulo      string    `json:"titulo"`
+	Descripcion string    `json:"descripcion"`
+	Autor       *Autor    `json:"autor"`
+	CreadoEn    time.Time `json:"creado_en"`
+}
+
+type Autor struct {
+	ID_Usuario int    `json:"id_usuario"`
+	Nombre     string `json:"nombre"`
+	Apellido   string `json:"apellido"`
+}
+
+// LogroRevisionsModel lee el historial que LogrosModel escribe
+type LogroRevisionsModel struct {
+	DB *sql.DB
+}
+
+const revisionColumns = `lr.revision, lr.titulo, lr.descripcion, lr.creado_en, u.id_usuario, u.nombre, u.apellido
+	FROM logro_revision lr LEFT JOIN usuario u ON u.id_usuario = lr.id_usuario`
+
+// ForLogro regresa todas las revisiones del logro, de la más antigua a la más
+// reciente.
+func (m *LogroRevisionsModel) ForLogro(id int) ([]LogroRevision, error) {
+	stmt := `SELECT ` + revisionColumns + ` WHERE lr.id_logro = ? ORDER BY lr.revision`
+
+	rows, err := m.DB.Query(stmt, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisiones []LogroRevision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisiones = append(revisiones, rev)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return revisiones, nil
+}
+
+func (m *LogroRevisionsModel) Get(id int, revision int) (LogroRevision, error) {
+	stmt := `SELECT ` + revisionColumns + ` WHERE lr.id_logro = ? AND lr.revision = ?`
+
+	rev, err := scanRevision(m.DB.QueryRow(stmt, id, revision))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return LogroRevision{}, ErrNoRecord
+		}
+		return LogroRevision{}, err
+	}
+	return rev, nil
+}
+
+func scanRevision(row interface{ Scan(...any) error }) (LogroRevision, error) {
+	var (
+		rev      LogroRevision
+		autorID  sql.NullInt64
+		nombre   sql.NullString
+		apellido sql.NullString
+	)
+	err := row.Scan(&rev.Revision, &rev.Titulo, &rev.Descripcion, &rev.CreadoEn, &autorID, &nombre, &apellido)
+	if err != nil {
+		return LogroRevision{}, err
+	}
+	if autorID.Valid {
+		rev.Autor = &Autor{ID_Usuario: int(autorID.Int64), Nombre: nombre.String, Apellido: apellido.String}
+	}
+	return rev, nil
+}
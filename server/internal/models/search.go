@@ -0,0 +1,63 @@
+package models
+
+import (
+	"crud-web/internal/search"
+	"database/sql"
+)
+
+// SearchModel implementa search.Index con el índice FULLTEXT de MySQL sobre
+// logro(titulo, descripcion). MySQL mantiene el índice al día, así que Upsert,
+// Delete y DeleteUser no hacen nada.
+type SearchModel struct {
+	DB *sql.DB
+}
+
+// Search elige primero los limit registros del usuario con el logro más
+// relevante y luego regresa los logros de esos registros que coinciden.
+func (m *SearchModel) Search(userID int, query string, limit int) ([]search.Hit, error) {
+	stmt := `SELECT l.id_logro, r.id_registro, r.id_usuario, r.inicio_semana, r.fin_semana, l.titulo, l.descripcion,
+	MATCH(l.titulo, l.descripcion) AGAINST (? IN NATURAL LANGUAGE MODE) AS relevancia
+	FROM (
+		SELECT r2.id_registro, MAX(MATCH(l2.titulo, l2.descripcion) AGAINST (? IN NATURAL LANGUAGE MODE)) AS mejor
+		FROM registro r2 JOIN logro l2 ON l2.id_registro = r2.id_registro
+		WHERE r2.id_usuario = ? AND r2.eliminado_en IS NULL AND MATCH(l2.titulo, l2.descripcion) AGAINST (? IN NATURAL LANGUAGE MODE)
+		GROUP BY r2.id_registro, r2.inicio_semana
+		ORDER BY mejor DESC, r2.inicio_semana DESC, r2.id_registro DESC LIMIT ?
+	) top
+	JOIN registro r ON r.id_registro = top.id_registro
+	JOIN logro l ON l.id_registro = r.id_registro
+	WHERE MATCH(l.titulo, l.descripcion) AGAINST (? IN NATURAL LANGUAGE MODE)
+	ORDER BY relevancia DESC, r.inicio_semana DESC, l.id_logro DESC`
+
+	rows, err := m.DB.Query(stmt, query, query, userID, query, limit, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []search.Hit
+	for rows.Next() {
+		var h search.Hit
+		err = rows.Scan(&h.LogroID, &h.RegistroID, &h.UserID, &h.InicioSemana, &h.FinSemana, &h.Titulo, &h.Descripcion, &h.Score)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+func (m *SearchModel) Upsert(doc search.Document) error {
+	return nil
+}
+
+func (m *SearchModel) Delete(logroID int) error {
+	return nil
+}
+
+func (m *SearchModel) DeleteUser(userID int) error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	ScopePasswordReset     = "password_reset"
+	ScopeEmailVerification = "email_verification"
+	ScopeMagicLink         = "magic_link"
+	ScopeMFAPending        = "mfa_pending"
+)
+
+type TokensModel struct {
+	DB *sql.DB
+}
+
+func (m *TokensModel) Insert(userID int, scope, tokenHash string, expiraEn time.Time) error {
+	stmt := `INSERT INTO token (token_hash, id_usuario, proposito, expira_en) VALUES(?, ?, ?, ?)`
+	_, err := m.DB.Exec(stmt, tokenHash, userID, scope, expiraEn)
+	return err
+}
+
+// Consume marca el token como usado y regresa el id de su usuario. El UPDATE
+// condicional garantiza que un token solo pueda canjearse una vez aunque lleguen
+// dos requests al mismo tiempo.
+func (m *TokensModel) Consume(scope, tokenHash string) (int, error) {
+	now := time.Now()
+	stmt := `UPDATE token SET usado_en = ?
+	WHERE token_hash = ? AND proposito = ? AND usado_en IS NULL AND expira_en > ?`
+	result, err := m.DB.Exec(stmt, now, tokenHash, scope, now)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if rowsAffected == 0 {
+		return 0, ErrInvalidToken
+	}
+
+	var userID int
+	stmt = `SELECT id_usuario FROM token WHERE token_hash = ?`
+	err = m.DB.QueryRow(stmt, tokenHash).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (m *TokensModel) DeleteAllForUser(scope string, userID int) error {
+	stmt := `DELETE FROM token WHERE proposito = ? AND id_usuario = ?`
+	_, err := m.DB.Exec(stmt, scope, userID)
+	return err
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// DBTX es lo que tienen en común *sql.DB y *sql.Tx. Los modelos que pueden
+// participar en una transacción guardan un DBTX en lugar de un *sql.DB.
+type DBTX interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Repos agrupa los modelos que comparten una transacción dentro de WithTx
+type Repos struct {
+	Registros *RegistrosModel
+	Logros    *LogrosModel
+}
+
+// UnitOfWork ejecuta varias operaciones de los modelos como una sola unidad
+type UnitOfWork struct {
+	DB *sql.DB
+}
+
+// txAttempts es cuántas veces WithTx intenta una transacción que InnoDB aborta
+// por deadlock antes de regresar el error.
+const txAttempts = 3
+
+// WithTx ejecuta fn con modelos que trabajan sobre una misma transacción. Si fn
+// regresa un error (o hace panic) se hace rollback de todo; si no, commit.
+//
+// Si InnoDB aborta la transacción por deadlock (error 1213) ya hizo rollback de
+// todo, así que WithTx vuelve a correr fn desde el principio en una transacción
+// nueva. Por eso fn no debe cambiar estado fuera de la transacción más allá de
+// asignar sus resultados.
+func (u *UnitOfWork) WithTx(fn func(repos Repos) error) error {
+	var err error
+	for attempt := 0; attempt < txAttempts; attempt++ {
+		err = u.withTx(fn)
+		if !isDeadlock(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (u *UnitOfWork) withTx(fn func(repos Repos) error) error {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(Repos{
+		Registros: &RegistrosModel{DB: tx},
+		Logros:    &LogrosModel{DB: tx},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func isDeadlock(err error) bool {
+	var mySQLError *mysql.MySQLError
+	return errors.As(err, &mySQLError) && mySQLError.Number == 1213
+}
@@ -5,11 +5,26 @@ import (
 	"errors"
 )
 
+const (
+	RoleUser    = "user"
+	RoleManager = "manager"
+	RoleAdmin   = "admin"
+)
+
+var Roles = []string{RoleUser, RoleManager, RoleAdmin}
+
 type User struct {
-	ID int `json: "id_usuario"`
-	Nombre string `json: "nombre"`
-	Apellido string `json: "apellido"`
-	Email string `json: "email"`
+	ID int `json:"id_usuario"`
+	Nombre string `json:"nombre"`
+	Apellido string `json:"apellido"`
+	Email string `json:"email"`
+	Verificado bool `json:"verificado"`
+	Rol string `json:"rol"`
+	Deshabilitado bool `json:"deshabilitado"`
+	TOTPHabilitado bool `json:"totp_habilitado"`
+	TOTPSecret string `json:"-"`
+	TOTPUltimoPaso int64 `json:"-"`
+	VersionSesion int `json:"-"`
     Password string `json:"-"`
 }
 
@@ -31,11 +46,13 @@ func (m *UsersModel) InsertWithPassword(nombre, apellido, email, hashedPassword
 }
 
 func (m *UsersModel) GetByEmail(email string) (User, error) {
-	stmt := `SELECT id_usuario, nombre, apellido, email, password FROM usuario WHERE email = ?`
+	stmt := `SELECT id_usuario, nombre, apellido, email, verificado, rol, deshabilitado,
+	totp_habilitado, COALESCE(totp_secret, ''), totp_ultimo_paso, version_sesion, password FROM usuario WHERE email = ?`
 	row := m.DB.QueryRow(stmt, email)
 
 	var u User
-	err := row.Scan(&u.ID, &u.Nombre, &u.Apellido, &u.Email, &u.Password)
+	err := row.Scan(&u.ID, &u.Nombre, &u.Apellido, &u.Email, &u.Verificado, &u.Rol, &u.Deshabilitado,
+		&u.TOTPHabilitado, &u.TOTPSecret, &u.TOTPUltimoPaso, &u.VersionSesion, &u.Password)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return User{}, ErrNoRecord
@@ -44,3 +61,171 @@ func (m *UsersModel) GetByEmail(email string) (User, error) {
 	}
 	return u, nil
 }
+
+func (m *UsersModel) Get(id int) (User, error) {
+	stmt := `SELECT id_usuario, nombre, apellido, email, verificado, rol, deshabilitado,
+	totp_habilitado, COALESCE(totp_secret, ''), totp_ultimo_paso, version_sesion, password FROM usuario WHERE id_usuario = ?`
+	row := m.DB.QueryRow(stmt, id)
+
+	var u User
+	err := row.Scan(&u.ID, &u.Nombre, &u.Apellido, &u.Email, &u.Verificado, &u.Rol, &u.Deshabilitado,
+		&u.TOTPHabilitado, &u.TOTPSecret, &u.TOTPUltimoPaso, &u.VersionSesion, &u.Password)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, ErrNoRecord
+		}
+		return User{}, err
+	}
+	return u, nil
+}
+
+func (m *UsersModel) UpdatePassword(id int, hashedPassword string) error {
+	stmt := `UPDATE usuario SET password = ? WHERE id_usuario = ?`
+	result, err := m.DB.Exec(stmt, hashedPassword, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// RevokeSessions sube la versión de sesión del usuario, con lo que dejan de
+// valer todos los access tokens que ya se le emitieron
+func (m *UsersModel) RevokeSessions(id int) error {
+	stmt := `UPDATE usuario SET version_sesion = version_sesion + 1 WHERE id_usuario = ?`
+	_, err := m.DB.Exec(stmt, id)
+	return err
+}
+
+func (m *UsersModel) SetVerified(id int) error {
+	stmt := `UPDATE usuario SET verificado = TRUE WHERE id_usuario = ?`
+	_, err := m.DB.Exec(stmt, id)
+	return err
+}
+
+// SetTOTPSecret guarda un secreto TOTP pendiente de confirmación. Mientras no se
+// llame a EnableTOTP, el login no lo exige.
+func (m *UsersModel) SetTOTPSecret(id int, secret string) error {
+	stmt := `UPDATE usuario SET totp_secret = ?, totp_habilitado = FALSE, totp_ultimo_paso = 0
+	WHERE id_usuario = ?`
+	_, err := m.DB.Exec(stmt, secret, id)
+	return err
+}
+
+func (m *UsersModel) EnableTOTP(id int) error {
+	stmt := `UPDATE usuario SET totp_habilitado = TRUE WHERE id_usuario = ? AND totp_secret IS NOT NULL`
+	_, err := m.DB.Exec(stmt, id)
+	return err
+}
+
+func (m *UsersModel) DisableTOTP(id int) error {
+	stmt := `UPDATE usuario SET totp_secret = NULL, totp_habilitado = FALSE, totp_ultimo_paso = 0
+	WHERE id_usuario = ?`
+	_, err := m.DB.Exec(stmt, id)
+	return err
+}
+
+// UseTOTPStep registra el paso de tiempo de un código TOTP aceptado. Regresa
+// ErrInvalidToken si ese paso (o uno posterior) ya se había usado, para que un
+// código interceptado no pueda reutilizarse.
+func (m *UsersModel) UseTOTPStep(id int, step int64) error {
+	stmt := `UPDATE usuario SET totp_ultimo_paso = ? WHERE id_usuario = ? AND totp_ultimo_paso < ?`
+	result, err := m.DB.Exec(stmt, step, id, step)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+func (m *UsersModel) UpdateProfile(id int, nombre, apellido, email string, verificado bool) error {
+	stmt := `UPDATE usuario SET nombre = ?, apellido = ?, email = ?, verificado = ? WHERE id_usuario = ?`
+	_, err := m.DB.Exec(stmt, nombre, apellido, email, verificado, id)
+	return err
+}
+
+// Delete elimina al usuario junto con sus registros, en una sola transacción.
+// Los logros de esos registros y las demás tablas que apuntan a usuario
+// (tokens, códigos de recuperación, etc.) se borran por ON DELETE CASCADE.
+func (m *UsersModel) Delete(id int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM registro WHERE id_usuario = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM usuario WHERE id_usuario = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return tx.Commit()
+}
+
+func (m *UsersModel) List() ([]User, error) {
+	stmt := `SELECT id_usuario, nombre, apellido, email, verificado, rol, deshabilitado, totp_habilitado
+	FROM usuario ORDER BY id_usuario`
+	rows, err := m.DB.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		var u User
+		err = rows.Scan(&u.ID, &u.Nombre, &u.Apellido, &u.Email, &u.Verificado, &u.Rol, &u.Deshabilitado, &u.TOTPHabilitado)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (m *UsersModel) SetRole(id int, rol string) error {
+	stmt := `UPDATE usuario SET rol = ? WHERE id_usuario = ?`
+	_, err := m.DB.Exec(stmt, rol, id)
+	return err
+}
+
+func (m *UsersModel) SetDisabled(id int, deshabilitado bool) error {
+	stmt := `UPDATE usuario SET deshabilitado = ? WHERE id_usuario = ?`
+	_, err := m.DB.Exec(stmt, deshabilitado, id)
+	return err
+}
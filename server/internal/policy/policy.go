@@ -0,0 +1,51 @@
+package policy
+
+import "crud-web/internal/models"
+
+// Actor es quien intenta realizar una acción: el usuario autenticado y su rol.
+type Actor struct {
+	UserID int
+	Role   string
+}
+
+type Action string
+
+const (
+	ReadRegistro   Action = "registro:read"
+	UpdateRegistro Action = "registro:update"
+	DeleteRegistro Action = "registro:delete"
+	RevokeAPIToken Action = "api_token:revoke"
+	ManageUsers    Action = "users:manage"
+)
+
+// Can decide si el actor puede realizar la acción sobre un recurso cuyo dueño
+// es ownerID. Es el único lugar donde se definen las reglas de autorización:
+//
+//   - admin puede todo.
+//   - manager puede leer los registros de cualquier usuario, pero solo
+//     modificar los suyos.
+//   - user solo puede leer y modificar sus propios registros.
+//   - los tokens de acceso personal solo los revoca su dueño (o un admin).
+//
+// Un rol vacío (tokens emitidos antes de que existieran los roles) se trata
+// como user.
+func Can(actor Actor, action Action, ownerID int) bool {
+	if actor.UserID < 1 {
+		return false
+	}
+
+	switch actor.Role {
+	case models.RoleAdmin:
+		return true
+	case models.RoleManager:
+		if action == ReadRegistro {
+			return true
+		}
+	}
+
+	switch action {
+	case ReadRegistro, UpdateRegistro, DeleteRegistro, RevokeAPIToken:
+		return actor.UserID == ownerID
+	}
+	return false
+}
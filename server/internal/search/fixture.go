@@ -0,0 +1,25 @@
+package search
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Load lee un arreglo JSON de documentos (ver los tags de Document) y los
+// agrega a index con Upsert. Sirve para llenar un MemoryIndex en pruebas o en
+// instalaciones sin MySQL.
+func Load(index Index, r io.Reader) error {
+	var docs []Document
+	err := json.NewDecoder(r).Decode(&docs)
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		err = index.Upsert(doc)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// tituloWeight hace que una coincidencia en el título pese más que una en la
+// descripción, igual que en la mayoría de los buscadores.
+const tituloWeight = 2
+
+type memoryEntry struct {
+	doc   Document
+	terms map[string]int
+}
+
+// MemoryIndex es un índice invertido en memoria con ranking TF-IDF. Sirve para
+// bases de datos sin FULLTEXT (SQLite) y para pruebas: no consulta ninguna base
+// de datos, solo conoce lo que recibe por Upsert (las escrituras de la API o un
+// fixture cargado con Load). Se pierde al reiniciar.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	entries  map[int]*memoryEntry
+	postings map[string]map[int]bool
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		entries:  make(map[int]*memoryEntry),
+		postings: make(map[string]map[int]bool),
+	}
+}
+
+func (m *MemoryIndex) Upsert(doc Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(doc.LogroID)
+
+	terms := make(map[string]int)
+	for _, t := range tokenize(doc.Titulo) {
+		terms[t.word] += tituloWeight
+	}
+	for _, t := range tokenize(doc.Descripcion) {
+		terms[t.word]++
+	}
+
+	m.entries[doc.LogroID] = &memoryEntry{doc: doc, terms: terms}
+	for term := range terms {
+		if m.postings[term] == nil {
+			m.postings[term] = make(map[int]bool)
+		}
+		m.postings[term][doc.LogroID] = true
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Delete(logroID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(logroID)
+	return nil
+}
+
+func (m *MemoryIndex) DeleteUser(userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for logroID, entry := range m.entries {
+		if entry.doc.UserID == userID {
+			m.remove(logroID)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryIndex) remove(logroID int) {
+	entry, ok := m.entries[logroID]
+	if !ok {
+		return
+	}
+	for term := range entry.terms {
+		delete(m.postings[term], logroID)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	delete(m.entries, logroID)
+}
+
+// Search regresa los documentos del usuario que contienen alguno de los
+// términos, ordenados por la suma de TF-IDF de cada término. Los empates se
+// resuelven por la semana más reciente. Solo incluye los documentos de los
+// limit registros con el mejor documento.
+func (m *MemoryIndex) Search(userID int, query string, limit int) ([]Hit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	total := float64(len(m.entries))
+	scores := make(map[int]float64)
+	for _, term := range Terms(query) {
+		postings := m.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + total/float64(len(postings)))
+		for logroID := range postings {
+			entry := m.entries[logroID]
+			if entry.doc.UserID != userID {
+				continue
+			}
+			scores[logroID] += float64(entry.terms[term]) * idf
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for logroID, score := range scores {
+		hits = append(hits, Hit{Document: m.entries[logroID].doc, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !hits[i].InicioSemana.Equal(hits[j].InicioSemana) {
+			return hits[i].InicioSemana.After(hits[j].InicioSemana)
+		}
+		return hits[i].LogroID > hits[j].LogroID
+	})
+
+	// Los hits van de mayor a menor Score, así que el primero de cada
+	// registro es su mejor documento.
+	registros := make(map[int]bool)
+	kept := hits[:0]
+	for _, hit := range hits {
+		if !registros[hit.RegistroID] {
+			if len(registros) == limit {
+				continue
+			}
+			registros[hit.RegistroID] = true
+		}
+		kept = append(kept, hit)
+	}
+	return kept, nil
+}
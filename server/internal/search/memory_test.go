@@ -0,0 +1,147 @@
+package search
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadFixture(t *testing.T) *MemoryIndex {
+	f, err := os.Open("testdata/logros.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	index := NewMemoryIndex()
+	err = Load(index, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+// registrosOf regresa, en orden, los registros y los logros de cada uno
+func registrosOf(results []Result) [][]int {
+	out := [][]int{}
+	for _, result := range results {
+		ids := []int{result.RegistroID}
+		for _, hit := range result.Hits {
+			ids = append(ids, hit.LogroID)
+		}
+		out = append(out, ids)
+	}
+	return out
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	index := loadFixture(t)
+
+	tests := []struct {
+		name   string
+		userID int
+		query  string
+		limit  int
+		want   [][]int
+	}{
+		{name: "registro with two matches ranks by its best logro", userID: 7, query: "migración", limit: 10, want: [][]int{{10, 1, 2}}},
+		{name: "title matches weigh more", userID: 7, query: "pagos", limit: 10, want: [][]int{{11, 3}, {10, 2}}},
+		{name: "limit counts registros, not logros", userID: 7, query: "pagos migración revisión", limit: 1, want: [][]int{{10, 2, 1}}},
+		{name: "only the caller's logros", userID: 8, query: "migración", limit: 10, want: [][]int{{20, 5}}},
+		{name: "case-insensitive", userID: 7, query: "MIGRACIÓN", limit: 10, want: [][]int{{10, 1, 2}}},
+		{name: "no match", userID: 7, query: "vacaciones", limit: 10, want: [][]int{}},
+		{name: "blank query", userID: 7, query: "  ", limit: 10, want: [][]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := index.Search(tt.userID, tt.query, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := registrosOf(Group(hits))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%d, %q, %d) = %v; want %v", tt.userID, tt.query, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexUpdates(t *testing.T) {
+	index := loadFixture(t)
+
+	err := index.Upsert(Document{LogroID: 4, RegistroID: 12, UserID: 7, InicioSemana: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+		Titulo: "Onboarding de pagos", Descripcion: "Expliqué el módulo de pagos"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Delete(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := index.Search(7, "pagos", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := registrosOf(Group(hits)), [][]int{{12, 4}, {10, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after Upsert and Delete: %v; want %v", got, want)
+	}
+
+	err = index.DeleteUser(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hits, err = index.Search(7, "pagos", 10)
+	if err != nil || len(hits) != 0 {
+		t.Errorf("after DeleteUser: %v, %v; want no hits", hits, err)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	semana := func(day int) time.Time { return time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC) }
+	hit := func(logro, registro int, inicio time.Time, score float64) Hit {
+		return Hit{Document: Document{LogroID: logro, RegistroID: registro, InicioSemana: inicio}, Score: score}
+	}
+
+	tests := []struct {
+		name string
+		hits []Hit
+		want [][]int
+	}{
+		{name: "empty", hits: nil, want: [][]int{}},
+		{
+			name: "best logro decides",
+			hits: []Hit{hit(1, 10, semana(5), 1), hit(2, 11, semana(12), 2), hit(3, 10, semana(5), 3)},
+			want: [][]int{{10, 3, 1}, {11, 2}},
+		},
+		{
+			name: "ties go to the most recent week",
+			hits: []Hit{hit(1, 10, semana(5), 2), hit(2, 11, semana(12), 2)},
+			want: [][]int{{11, 2}, {10, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Group(tt.hits)
+			if got := registrosOf(results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Group = %v; want %v", got, tt.want)
+			}
+			for _, result := range results {
+				if result.Score != result.Hits[0].Score {
+					t.Errorf("registro %d Score = %v; want its best hit %v", result.RegistroID, result.Score, result.Hits[0].Score)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadRejectsBadJSON(t *testing.T) {
+	index := NewMemoryIndex()
+	if err := Load(index, strings.NewReader(`{"id_logro": 1}`)); err == nil {
+		t.Error("Load accepted an object instead of an array")
+	}
+}
@@ -0,0 +1,199 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Document es lo que se indexa de cada logro: su texto y los datos del registro
+// al que pertenece, para poder armar la respuesta sin consultas adicionales.
+// Los tags json son el formato de los fixtures que lee Load.
+type Document struct {
+	LogroID      int       `json:"id_logro"`
+	RegistroID   int       `json:"id_registro"`
+	UserID       int       `json:"id_usuario"`
+	InicioSemana time.Time `json:"inicio_semana"`
+	FinSemana    time.Time `json:"fin_semana"`
+	Titulo       string    `json:"titulo"`
+	Descripcion  string    `json:"descripcion"`
+}
+
+// Hit es un documento encontrado junto con su relevancia. Un Score mayor es más
+// relevante; la escala depende de la implementación.
+type Hit struct {
+	Document
+	Score float64
+}
+
+// Result es un registro encontrado: sus logros que coinciden, del más relevante
+// al menos, y como Score el de su mejor logro.
+type Result struct {
+	RegistroID   int
+	UserID       int
+	InicioSemana time.Time
+	FinSemana    time.Time
+	Score        float64
+	Hits         []Hit
+}
+
+// Index busca logros por palabras clave. Search regresa los logros que
+// coinciden de los limit registros más relevantes del usuario, donde cada
+// registro vale lo que su logro más relevante; Group los junta por registro.
+// Upsert, Delete y DeleteUser mantienen el índice al día; las implementaciones
+// respaldadas por la base de datos (FULLTEXT) pueden ignorarlos porque el motor
+// actualiza el índice solo.
+type Index interface {
+	Search(userID int, query string, limit int) ([]Hit, error)
+	Upsert(doc Document) error
+	Delete(logroID int) error
+	// DeleteUser quita todos los logros del usuario, al eliminar su cuenta
+	DeleteUser(userID int) error
+}
+
+// Group junta los hits por registro. Los registros quedan ordenados por su
+// mejor Score (los empates, por la semana más reciente) y los hits de cada uno
+// también por Score.
+func Group(hits []Hit) []Result {
+	var results []Result
+	index := make(map[int]int)
+	for _, hit := range hits {
+		i, ok := index[hit.RegistroID]
+		if !ok {
+			i = len(results)
+			index[hit.RegistroID] = i
+			results = append(results, Result{
+				RegistroID:   hit.RegistroID,
+				UserID:       hit.UserID,
+				InicioSemana: hit.InicioSemana,
+				FinSemana:    hit.FinSemana,
+				Score:        hit.Score,
+			})
+		}
+		results[i].Hits = append(results[i].Hits, hit)
+		results[i].Score = max(results[i].Score, hit.Score)
+	}
+
+	for _, result := range results {
+		sort.SliceStable(result.Hits, func(i, j int) bool {
+			return result.Hits[i].Score > result.Hits[j].Score
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if !results[i].InicioSemana.Equal(results[j].InicioSemana) {
+			return results[i].InicioSemana.After(results[j].InicioSemana)
+		}
+		return results[i].RegistroID > results[j].RegistroID
+	})
+	return results
+}
+
+type token struct {
+	word       string
+	start, end int
+}
+
+// tokenize separa s en palabras (letras y dígitos) en minúsculas, guardando su
+// posición en bytes dentro de s.
+func tokenize(s string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range s {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if inWord && start < 0 {
+			start = i
+		}
+		if !inWord && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(s[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(s[start:]), start, len(s)})
+	}
+	return tokens
+}
+
+// Terms regresa las palabras distintas de una búsqueda
+func Terms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, t := range tokenize(query) {
+		if !seen[t.word] {
+			seen[t.word] = true
+			terms = append(terms, t.word)
+		}
+	}
+	return terms
+}
+
+// Highlight regresa un fragmento de text de alrededor de width bytes centrado en
+// la primera palabra que coincide con terms. El texto se escapa como HTML y las
+// coincidencias se envuelven en <mark></mark>, así el cliente puede insertarlo
+// directamente. Si ninguna palabra coincide regresa el inicio del texto.
+func Highlight(text string, terms []string, width int) string {
+	wanted := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		wanted[t] = true
+	}
+
+	tokens := tokenize(text)
+	first := -1
+	for i, t := range tokens {
+		if wanted[t.word] {
+			first = i
+			break
+		}
+	}
+
+	from, to := 0, len(text)
+	if len(text) > width {
+		if first >= 0 {
+			from = tokens[first].start - width/3
+		}
+		from = max(from, 0)
+		to = min(from+width, len(text))
+		// Ajustar los cortes a límites de palabra
+		for _, t := range tokens {
+			if t.start < from && t.end > from {
+				from = t.start
+			}
+			if t.start < to && t.end > to {
+				to = t.end
+			}
+		}
+		for from > 0 && !utf8.RuneStart(text[from]) {
+			from--
+		}
+		for to < len(text) && !utf8.RuneStart(text[to]) {
+			to++
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, t := range tokens {
+		if t.start < from || t.end > to || !wanted[t.word] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:t.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[t.start:t.end]))
+		b.WriteString("</mark>")
+		pos = t.end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,156 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// Record es el estado de los intentos fallidos de una llave (por ejemplo un
+// email o una IP).
+type Record struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Store guarda los Record por llave. Increment debe ser atómico para que dos
+// intentos simultáneos no se pierdan.
+type Store interface {
+	Get(key string) (Record, error)
+	// Increment suma un fallo a la llave. Si el último fallo es anterior a
+	// now-window, el conteo vuelve a empezar en 1.
+	Increment(key string, now time.Time, window time.Duration) (Record, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+// Policy define cuántos fallos se toleran y cuánto crece el bloqueo.
+type Policy struct {
+	// MaxFailures es el número de fallos permitidos antes del primer bloqueo.
+	MaxFailures int
+	// BaseDelay es la duración del primer bloqueo; cada fallo adicional la duplica.
+	BaseDelay time.Duration
+	// MaxDelay es el tope del bloqueo.
+	MaxDelay time.Duration
+	// Window es el tiempo sin fallos después del cual se olvida el conteo.
+	Window time.Duration
+}
+
+// Limiter aplica una Policy sobre un Store con backoff exponencial.
+type Limiter struct {
+	Store  Store
+	Policy Policy
+}
+
+// Check regresa cuánto falta para que la llave pueda volver a intentar, o cero
+// si no está bloqueada.
+func (l *Limiter) Check(key string, now time.Time) (time.Duration, error) {
+	rec, err := l.Store.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if rec.LockedUntil.After(now) {
+		return rec.LockedUntil.Sub(now), nil
+	}
+	return 0, nil
+}
+
+// Fail registra un intento fallido. Si se superó MaxFailures bloquea la llave y
+// regresa la duración del bloqueo.
+func (l *Limiter) Fail(key string, now time.Time) (Record, time.Duration, error) {
+	rec, err := l.Store.Increment(key, now, l.Policy.Window)
+	if err != nil {
+		return Record{}, 0, err
+	}
+
+	if rec.Failures < l.Policy.MaxFailures {
+		return rec, 0, nil
+	}
+
+	delay := l.backoff(rec.Failures)
+	rec.LockedUntil = now.Add(delay)
+	err = l.Store.Lock(key, rec.LockedUntil)
+	if err != nil {
+		return Record{}, 0, err
+	}
+	return rec, delay, nil
+}
+
+// Succeed olvida los fallos de la llave.
+func (l *Limiter) Succeed(key string) error {
+	return l.Store.Reset(key)
+}
+
+func (l *Limiter) backoff(failures int) time.Duration {
+	delay := l.Policy.BaseDelay
+	for i := l.Policy.MaxFailures; i < failures; i++ {
+		delay *= 2
+		if delay >= l.Policy.MaxDelay {
+			return l.Policy.MaxDelay
+		}
+	}
+	return min(delay, l.Policy.MaxDelay)
+}
+
+// MemoryStore es un Store en memoria, suficiente para una sola instancia del
+// servidor. Los datos se pierden al reiniciar.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+func (s *MemoryStore) Get(key string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[key], nil
+}
+
+func (s *MemoryStore) Increment(key string, now time.Time, window time.Duration) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now, window)
+
+	rec := s.records[key]
+	if now.Sub(rec.LastFailure) > window {
+		rec.Failures = 0
+	}
+	rec.Failures++
+	rec.LastFailure = now
+	s.records[key] = rec
+	return rec, nil
+}
+
+func (s *MemoryStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.records[key]
+	rec.LockedUntil = until
+	s.records[key] = rec
+	return nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// prune elimina las llaves sin fallos recientes ni bloqueo vigente, para que el
+// mapa no crezca sin límite con IPs de un solo intento.
+func (s *MemoryStore) prune(now time.Time, window time.Duration) {
+	if len(s.records) < 10000 {
+		return
+	}
+	for key, rec := range s.records {
+		if now.Sub(rec.LastFailure) > window && !rec.LockedUntil.After(now) {
+			delete(s.records, key)
+		}
+	}
+}
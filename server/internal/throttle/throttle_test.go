@@ -0,0 +1,91 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	l := &Limiter{Policy: Policy{MaxFailures: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 3, want: time.Second},
+		{failures: 4, want: 2 * time.Second},
+		{failures: 5, want: 4 * time.Second},
+		{failures: 6, want: 8 * time.Second},
+		{failures: 7, want: 10 * time.Second},
+		{failures: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := l.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %s; want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffBaseAboveMax(t *testing.T) {
+	l := &Limiter{Policy: Policy{MaxFailures: 1, BaseDelay: time.Minute, MaxDelay: time.Second}}
+	if got := l.backoff(1); got != time.Second {
+		t.Errorf("backoff(1) = %s; want %s", got, time.Second)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := &Limiter{
+		Store:  NewMemoryStore(),
+		Policy: Policy{MaxFailures: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour},
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	_, lock, err := l.Fail("email:a@example.com", now)
+	if err != nil || lock != 0 {
+		t.Fatalf("first failure: lock = %s, err = %v; want no lock", lock, err)
+	}
+
+	_, lock, err = l.Fail("email:a@example.com", now)
+	if err != nil || lock != time.Minute {
+		t.Fatalf("second failure: lock = %s, err = %v; want %s", lock, err, time.Minute)
+	}
+
+	wait, err := l.Check("email:a@example.com", now.Add(20*time.Second))
+	if err != nil || wait != 40*time.Second {
+		t.Errorf("Check while locked = %s, %v; want %s", wait, err, 40*time.Second)
+	}
+
+	wait, err = l.Check("email:b@example.com", now)
+	if err != nil || wait != 0 {
+		t.Errorf("Check on another key = %s, %v; want 0", wait, err)
+	}
+
+	wait, err = l.Check("email:a@example.com", now.Add(time.Minute))
+	if err != nil || wait != 0 {
+		t.Errorf("Check after the lock = %s, %v; want 0", wait, err)
+	}
+
+	err = l.Succeed("email:a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, lock, err := l.Fail("email:a@example.com", now.Add(2*time.Minute))
+	if err != nil || rec.Failures != 1 || lock != 0 {
+		t.Errorf("failure after Succeed: failures = %d, lock = %s, err = %v; want 1 and no lock", rec.Failures, lock, err)
+	}
+}
+
+func TestLimiterWindow(t *testing.T) {
+	l := &Limiter{
+		Store:  NewMemoryStore(),
+		Policy: Policy{MaxFailures: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour},
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	l.Fail("ip:10.0.0.1", now)
+	rec, lock, err := l.Fail("ip:10.0.0.1", now.Add(2*time.Hour))
+	if err != nil || rec.Failures != 1 || lock != 0 {
+		t.Errorf("failure after the window: failures = %d, lock = %s, err = %v; want 1 and no lock", rec.Failures, lock, err)
+	}
+}
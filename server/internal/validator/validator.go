@@ -3,7 +3,9 @@ package validator
 import (
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -53,4 +55,58 @@ func MinChars(value string, n int) bool {
 
 func Matches(value string, rx *regexp.Regexp) bool {
     return rx.MatchString(value)
-}
\ No newline at end of file
+}
+
+// Date indica si value es una fecha con formato YYYY-MM-DD
+func Date(value string) bool {
+    _, err := time.Parse("2006-01-02", value)
+    return err == nil
+}
+
+// DateOrder indica si end es igual o posterior a start
+func DateOrder(start, end time.Time) bool {
+    return !end.Before(start)
+}
+
+// MaxSpanDays indica si el rango [start, end] cubre como máximo n días
+// contando ambos extremos (de lunes a domingo son 7).
+func MaxSpanDays(start, end time.Time, n int) bool {
+    return end.Sub(start) < time.Duration(n)*24*time.Hour
+}
+
+// NotAfter indica si date no es posterior a limit
+func NotAfter(date, limit time.Time) bool {
+    return !date.After(limit)
+}
+
+// WeekAligned indica si [start, end] es exactamente una semana que empieza en
+// weekStart.
+func WeekAligned(start, end time.Time, weekStart time.Weekday) bool {
+    return start.Weekday() == weekStart && end.Equal(start.AddDate(0, 0, 6))
+}
+
+var ISOWeekRX = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+
+// ISOWeek convierte una semana ISO 8601 como "2026-W42" en su primer y último
+// día. Las semanas ISO empiezan en lunes; con otro weekStart la semana se
+// recorre al weekStart anterior o igual a ese lunes. Regresa false si el valor
+// no tiene el formato o la semana no existe en ese año.
+func ISOWeek(value string, weekStart time.Weekday) (time.Time, time.Time, bool) {
+    m := ISOWeekRX.FindStringSubmatch(value)
+    if m == nil {
+        return time.Time{}, time.Time{}, false
+    }
+    year, _ := strconv.Atoi(m[1])
+    week, _ := strconv.Atoi(m[2])
+
+    // El 4 de enero siempre cae en la semana 1
+    jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+    monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*7)
+
+    if y, w := monday.ISOWeek(); week < 1 || y != year || w != week {
+        return time.Time{}, time.Time{}, false
+    }
+
+    start := monday.AddDate(0, 0, -((int(time.Monday)-int(weekStart)+7)%7))
+    return start, start.AddDate(0, 0, 6), true
+}
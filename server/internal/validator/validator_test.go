@@ -0,0 +1,108 @@
+package validator
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestISOWeek(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		weekStart time.Weekday
+		start     string
+		end       string
+		ok        bool
+	}{
+		{name: "first week crosses the year", value: "2026-W01", weekStart: time.Monday, start: "2025-12-29", end: "2026-01-04", ok: true},
+		{name: "mid year", value: "2026-W42", weekStart: time.Monday, start: "2026-10-12", end: "2026-10-18", ok: true},
+		{name: "week 53 in a long year", value: "2020-W53", weekStart: time.Monday, start: "2020-12-28", end: "2021-01-03", ok: true},
+		{name: "week 53 in a year that starts on Thursday", value: "2026-W53", weekStart: time.Monday, start: "2026-12-28", end: "2027-01-03", ok: true},
+		{name: "week 53 in a short year", value: "2025-W53", weekStart: time.Monday, ok: false},
+		{name: "week 00", value: "2026-W00", weekStart: time.Monday, ok: false},
+		{name: "week 54", value: "2026-W54", weekStart: time.Monday, ok: false},
+		{name: "sunday weeks start the day before", value: "2026-W01", weekStart: time.Sunday, start: "2025-12-28", end: "2026-01-03", ok: true},
+		{name: "saturday weeks", value: "2026-W42", weekStart: time.Saturday, start: "2026-10-10", end: "2026-10-16", ok: true},
+		{name: "lowercase w", value: "2026-w42", weekStart: time.Monday, ok: false},
+		{name: "single digit week", value: "2026-W4", weekStart: time.Monday, ok: false},
+		{name: "trailing text", value: "2026-W42x", weekStart: time.Monday, ok: false},
+		{name: "empty", value: "", weekStart: time.Monday, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := ISOWeek(tt.value, tt.weekStart)
+			if ok != tt.ok {
+				t.Fatalf("ISOWeek(%q) ok = %v; want %v", tt.value, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !start.Equal(date(tt.start)) || !end.Equal(date(tt.end)) {
+				t.Errorf("ISOWeek(%q) = %s..%s; want %s..%s", tt.value,
+					start.Format("2006-01-02"), end.Format("2006-01-02"), tt.start, tt.end)
+			}
+			if !WeekAligned(start, end, tt.weekStart) {
+				t.Errorf("ISOWeek(%q) is not aligned to %s", tt.value, tt.weekStart)
+			}
+		})
+	}
+}
+
+func TestWeekAligned(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		weekStart  time.Weekday
+		want       bool
+	}{
+		{name: "monday to sunday", start: "2026-10-12", end: "2026-10-18", weekStart: time.Monday, want: true},
+		{name: "starts on the wrong day", start: "2026-10-13", end: "2026-10-19", weekStart: time.Monday, want: false},
+		{name: "too short", start: "2026-10-12", end: "2026-10-16", weekStart: time.Monday, want: false},
+		{name: "sunday to saturday", start: "2026-10-11", end: "2026-10-17", weekStart: time.Sunday, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WeekAligned(date(tt.start), date(tt.end), tt.weekStart); got != tt.want {
+				t.Errorf("WeekAligned(%s, %s, %s) = %v; want %v", tt.start, tt.end, tt.weekStart, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxSpanDays(t *testing.T) {
+	tests := []struct {
+		start, end string
+		n          int
+		want       bool
+	}{
+		{start: "2026-10-12", end: "2026-10-18", n: 7, want: true},
+		{start: "2026-10-12", end: "2026-10-19", n: 7, want: false},
+		{start: "2026-10-12", end: "2026-10-12", n: 1, want: true},
+		{start: "2026-12-28", end: "2027-01-03", n: 7, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := MaxSpanDays(date(tt.start), date(tt.end), tt.n); got != tt.want {
+			t.Errorf("MaxSpanDays(%s, %s, %d) = %v; want %v", tt.start, tt.end, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestDateOrder(t *testing.T) {
+	if !DateOrder(date("2026-10-12"), date("2026-10-12")) {
+		t.Error("the same day should be in order")
+	}
+	if DateOrder(date("2026-10-12"), date("2026-10-11")) {
+		t.Error("an end before the start should not be in order")
+	}
+}
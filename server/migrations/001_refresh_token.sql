@@ -0,0 +1,18 @@
+-- Refresh tokens para renovar el access token sin volver a pedir credenciales.
+-- Solo se guarda el hash SHA-256 del token. Todos los tokens que salen de un
+-- mismo login comparten la misma familia, de modo que al detectar la reutilización
+-- de un token ya rotado se puede revocar la familia completa.
+CREATE TABLE refresh_token (
+    id_refresh_token INT AUTO_INCREMENT PRIMARY KEY,
+    id_usuario INT NOT NULL,
+    familia CHAR(32) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expira_en DATETIME NOT NULL,
+    usado_en DATETIME NULL,
+    revocado_en DATETIME NULL,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_refresh_token_hash (token_hash),
+    KEY idx_refresh_token_familia (familia),
+    CONSTRAINT fk_refresh_token_usuario FOREIGN KEY (id_usuario)
+        REFERENCES usuario (id_usuario) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+-- Tokens de un solo uso enviados por correo (reset de contraseña, y en el futuro
+-- otros propósitos). Solo se guarda el hash SHA-256 del token.
+CREATE TABLE token (
+    token_hash CHAR(64) PRIMARY KEY,
+    id_usuario INT NOT NULL,
+    proposito VARCHAR(32) NOT NULL,
+    expira_en DATETIME NOT NULL,
+    usado_en DATETIME NULL,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_token_usuario (id_usuario, proposito),
+    CONSTRAINT fk_token_usuario FOREIGN KEY (id_usuario)
+        REFERENCES usuario (id_usuario) ON DELETE CASCADE
+);
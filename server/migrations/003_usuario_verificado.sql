@@ -0,0 +1,5 @@
+-- Estado de verificación del email. Los usuarios que ya existían se marcan como
+-- verificados para no bloquearlos con la nueva política.
+ALTER TABLE usuario ADD COLUMN verificado BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE usuario SET verificado = TRUE;
@@ -0,0 +1,19 @@
+-- Autenticación de dos factores con TOTP (RFC 6238).
+-- totp_secret guarda el secreto en base32; mientras totp_habilitado sea FALSE el
+-- secreto está pendiente de confirmación. totp_ultimo_paso es el último paso de
+-- tiempo aceptado y evita que el mismo código se use dos veces.
+ALTER TABLE usuario
+    ADD COLUMN totp_secret VARCHAR(64) NULL,
+    ADD COLUMN totp_habilitado BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN totp_ultimo_paso BIGINT NOT NULL DEFAULT 0;
+
+-- Códigos de recuperación de un solo uso, guardados como hash SHA-256.
+CREATE TABLE codigo_recuperacion (
+    id_codigo INT AUTO_INCREMENT PRIMARY KEY,
+    id_usuario INT NOT NULL,
+    codigo_hash CHAR(64) NOT NULL,
+    usado_en DATETIME NULL,
+    UNIQUE KEY uq_codigo_recuperacion (id_usuario, codigo_hash),
+    CONSTRAINT fk_codigo_recuperacion_usuario FOREIGN KEY (id_usuario)
+        REFERENCES usuario (id_usuario) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+-- Intentos fallidos de login por llave ("email:..." o "ip:...") para el bloqueo
+-- temporal con backoff exponencial. Solo se usa con -login-throttle-store=mysql.
+CREATE TABLE intento_login (
+    llave VARCHAR(320) PRIMARY KEY,
+    fallos INT NOT NULL DEFAULT 0,
+    ultimo_fallo DATETIME NOT NULL,
+    bloqueado_hasta DATETIME NULL
+);
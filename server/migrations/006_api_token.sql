@@ -0,0 +1,16 @@
+-- Tokens de acceso personal para scripts e integraciones. Solo se guarda el hash
+-- SHA-256; scopes es una lista separada por espacios (p. ej. "registros:read").
+CREATE TABLE api_token (
+    id_api_token INT AUTO_INCREMENT PRIMARY KEY,
+    id_usuario INT NOT NULL,
+    nombre VARCHAR(100) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    expira_en DATETIME NULL,
+    ultimo_uso DATETIME NULL,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_api_token_hash (token_hash),
+    KEY idx_api_token_usuario (id_usuario),
+    CONSTRAINT fk_api_token_usuario FOREIGN KEY (id_usuario)
+        REFERENCES usuario (id_usuario) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+-- Roles y deshabilitación de cuentas. Para crear el primer administrador:
+--   UPDATE usuario SET rol = 'admin' WHERE email = '...';
+ALTER TABLE usuario
+    ADD COLUMN rol ENUM('user', 'manager', 'admin') NOT NULL DEFAULT 'user',
+    ADD COLUMN deshabilitado BOOLEAN NOT NULL DEFAULT FALSE;
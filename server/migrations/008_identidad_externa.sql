@@ -0,0 +1,14 @@
+-- Identidades de proveedores OIDC vinculadas a una cuenta local. Un mismo
+-- usuario puede tener varias; (emisor, sujeto) identifica a la persona en el
+-- proveedor de forma estable, aunque cambie su email.
+CREATE TABLE identidad_externa (
+    id_identidad INT AUTO_INCREMENT PRIMARY KEY,
+    id_usuario INT NOT NULL,
+    emisor VARCHAR(255) NOT NULL,
+    sujeto VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_identidad_externa (emisor, sujeto),
+    CONSTRAINT fk_identidad_externa_usuario FOREIGN KEY (id_usuario)
+        REFERENCES usuario (id_usuario) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+-- Fecha de última modificación de registros y logros, para los headers
+-- Last-Modified/ETag de GET /registros/{id}. MySQL la actualiza sola en cada
+-- UPDATE.
+ALTER TABLE registro
+    ADD COLUMN actualizado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
+
+ALTER TABLE logro
+    ADD COLUMN actualizado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
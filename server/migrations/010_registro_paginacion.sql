@@ -0,0 +1,3 @@
+-- Índice para la paginación por cursor de GET /registros, que ordena por
+-- (inicio_semana, id_registro) dentro de los registros de cada usuario.
+CREATE INDEX idx_registro_usuario_semana ON registro (id_usuario, inicio_semana, id_registro);
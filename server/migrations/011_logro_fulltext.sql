@@ -0,0 +1,3 @@
+-- Índice FULLTEXT para GET /search. Con el parser por defecto, MySQL ignora
+-- palabras de menos de innodb_ft_min_token_size (3) caracteres y las stopwords.
+ALTER TABLE logro ADD FULLTEXT INDEX ft_logro_texto (titulo, descripcion);
@@ -0,0 +1,5 @@
+-- Una semana no puede terminar antes de empezar. MySQL aplica CHECK desde 8.0.16.
+-- El traslape entre semanas de un mismo usuario no se puede expresar como
+-- constraint; lo revisa RegistrosModel.Insert según -duplicate-weeks.
+ALTER TABLE registro
+    ADD CONSTRAINT chk_registro_semana CHECK (fin_semana >= inicio_semana);
@@ -0,0 +1,6 @@
+-- Versión de cada fila para control de concurrencia optimista. Cada UPDATE desde
+-- la API la incrementa; PATCH y DELETE /registros/{id} exigen If-Match con el
+-- ETag que la contiene.
+ALTER TABLE registro ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE logro ADD COLUMN version INT NOT NULL DEFAULT 1;
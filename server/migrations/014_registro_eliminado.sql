@@ -0,0 +1,6 @@
+-- Borrado lógico (deleted_at) de registros. DELETE /registros/{id} solo llena
+-- eliminado_en; el registro queda en la papelera hasta que se restaura o el
+-- servidor lo purga al cumplirse -trash-retention.
+ALTER TABLE registro ADD COLUMN eliminado_en DATETIME NULL;
+
+CREATE INDEX idx_registro_eliminado ON registro (eliminado_en);
@@ -0,0 +1,23 @@
+-- Historial de titulo/descripcion de cada logro. LogrosModel.Insert y Update
+-- guardan una fila por versión (revision = logro.version) con el autor del
+-- cambio. Los logros que ya existían arrancan con su contenido actual como
+-- primera revisión; su historial anterior no se conoce.
+CREATE TABLE logro_revision (
+    id_revision INT AUTO_INCREMENT PRIMARY KEY,
+    id_logro INT NOT NULL,
+    revision INT NOT NULL,
+    titulo VARCHAR(255) NOT NULL,
+    descripcion TEXT NOT NULL,
+    id_usuario INT NULL,
+    creado_en DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_logro_revision (id_logro, revision),
+    CONSTRAINT fk_logro_revision_logro FOREIGN KEY (id_logro)
+        REFERENCES logro (id_logro) ON DELETE CASCADE,
+    CONSTRAINT fk_logro_revision_usuario FOREIGN KEY (id_usuario)
+        REFERENCES usuario (id_usuario) ON DELETE SET NULL
+);
+
+INSERT INTO logro_revision (id_logro, revision, titulo, descripcion, id_usuario, creado_en)
+SELECT l.id_logro, l.version, l.titulo, l.descripcion, MIN(r.id_usuario), l.actualizado_en
+FROM logro l LEFT JOIN registro r ON r.id_logro = l.id_logro
+GROUP BY l.id_logro, l.version, l.titulo, l.descripcion, l.actualizado_en;
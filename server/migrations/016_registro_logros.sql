@@ -0,0 +1,95 @@
+-- Un registro (una semana) pasa a tener una lista ordenada de logros en lugar
+-- de apuntar a uno solo. Cada logro guarda su registro y su posición; al
+-- eliminar un registro se eliminan sus logros (y su historial) por ON DELETE
+-- CASCADE.
+--
+-- Se puede volver a correr si falla a la mitad: cada paso revisa en
+-- information_schema si ya se aplicó, y los nombres de la llave foránea y el
+-- índice de registro.id_logro se buscan ahí porque el esquema original no los
+-- nombró. MySQL no tiene IF EXISTS para columnas ni constraints, así que los
+-- pasos condicionales se arman como texto y se ejecutan con PREPARE.
+
+SET @sql = (SELECT IF(COUNT(*) = 0,
+    'ALTER TABLE logro ADD COLUMN id_registro INT NULL',
+    'DO 0')
+    FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'logro' AND COLUMN_NAME = 'id_registro');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @sql = (SELECT IF(COUNT(*) = 0,
+    'ALTER TABLE logro ADD COLUMN posicion INT NOT NULL DEFAULT 0',
+    'DO 0')
+    FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'logro' AND COLUMN_NAME = 'posicion');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- Mientras exista registro.id_logro hay que copiar la relación a logro
+SET @sql = (SELECT IF(COUNT(*) = 0,
+    'DO 0',
+    'UPDATE logro l JOIN registro r ON r.id_logro = l.id_logro
+     SET l.id_registro = r.id_registro, l.posicion = 0
+     WHERE l.id_registro IS NULL')
+    FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'registro' AND COLUMN_NAME = 'id_logro');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- Los logros sin registro son los mismos que `maintenance orphans -fix` habría
+-- eliminado; ya no pueden existir.
+DELETE FROM logro WHERE id_registro IS NULL;
+
+ALTER TABLE logro MODIFY id_registro INT NOT NULL;
+
+SET @sql = (SELECT IF(COUNT(*) = 0,
+    'ALTER TABLE logro ADD KEY idx_logro_registro (id_registro, posicion)',
+    'DO 0')
+    FROM information_schema.STATISTICS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'logro' AND INDEX_NAME = 'idx_logro_registro');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @sql = (SELECT IF(COUNT(*) = 0,
+    'ALTER TABLE logro ADD CONSTRAINT fk_logro_registro FOREIGN KEY (id_registro)
+         REFERENCES registro (id_registro) ON DELETE CASCADE',
+    'DO 0')
+    FROM information_schema.TABLE_CONSTRAINTS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'logro' AND CONSTRAINT_NAME = 'fk_logro_registro');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- La llave foránea de registro.id_logro impide borrar la columna; primero se
+-- quitan las llaves y luego los índices que solo cubren id_logro.
+SET @sql = (SELECT IFNULL(CONCAT('ALTER TABLE registro ',
+        GROUP_CONCAT(CONCAT('DROP FOREIGN KEY `', CONSTRAINT_NAME, '`'))), 'DO 0')
+    FROM information_schema.KEY_COLUMN_USAGE
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'registro' AND COLUMN_NAME = 'id_logro'
+        AND REFERENCED_TABLE_NAME IS NOT NULL);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @sql = (SELECT IFNULL(CONCAT('ALTER TABLE registro ',
+        GROUP_CONCAT(CONCAT('DROP INDEX `', INDEX_NAME, '`'))), 'DO 0')
+    FROM (SELECT INDEX_NAME FROM information_schema.STATISTICS
+        WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'registro'
+        GROUP BY INDEX_NAME
+        HAVING SUM(COLUMN_NAME = 'id_logro') = COUNT(*)) i);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @sql = (SELECT IF(COUNT(*) = 0,
+    'DO 0',
+    'ALTER TABLE registro DROP COLUMN id_logro')
+    FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'registro' AND COLUMN_NAME = 'id_logro');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,14 @@
+-- Respaldo en la base de datos para -duplicate-weeks reject y merge. Un índice
+-- único sobre (id_usuario, inicio_semana) rompería el modo allow (el default),
+-- así que semana_unica copia inicio_semana solo en los registros guardados con
+-- reject o merge y queda en NULL con allow y en la papelera; MySQL permite
+-- varios NULL en un índice único. El traslape parcial entre semanas sigue sin
+-- poder expresarse como constraint: lo revisa RegistrosModel antes de escribir,
+-- y este índice atrapa lo que se le escape (dos semanas que empiezan el mismo
+-- día).
+--
+-- Los registros existentes quedan en NULL porque pueden tener duplicados de
+-- cuando la política era allow.
+ALTER TABLE registro
+    ADD COLUMN semana_unica DATE NULL,
+    ADD UNIQUE KEY uq_registro_semana (id_usuario, semana_unica);
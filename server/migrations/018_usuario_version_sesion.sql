@@ -0,0 +1,5 @@
+-- Versión de las sesiones del usuario. Cada access token (JWT) lleva la versión
+-- con la que se emitió y requireAuth rechaza los que no coinciden con la
+-- actual, así que subirla invalida de inmediato todos los tokens ya emitidos
+-- (por ejemplo al restablecer la contraseña).
+ALTER TABLE usuario ADD COLUMN version_sesion INT NOT NULL DEFAULT 0;